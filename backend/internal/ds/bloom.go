@@ -0,0 +1,72 @@
+package ds
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// BloomFilter es un filtro de Bloom: responde "seguro que no está" o "puede estar"
+// usando un arreglo de bits y k funciones hash, sin guardar los elementos. No admite
+// eliminaciones; los falsos positivos se resuelven con una verificación exacta aparte.
+type BloomFilter[T ~string | ~[]byte] struct {
+	bits   []uint64
+	m      uint64
+	k      uint64
+	count  int
+	expect int
+}
+
+// NewBloomFilter dimensiona el filtro para expected elementos con una tasa de falsos
+// positivos cercana a fpRate: m = -n·ln(p)/ln(2)² bits y k = (m/n)·ln(2) hashes.
+func NewBloomFilter[T ~string | ~[]byte](expected int, fpRate float64) *BloomFilter[T] {
+	if expected <= 0 {
+		expected = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		panic("bloom filter false-positive rate must be in (0, 1)")
+	}
+	m := uint64(math.Ceil(-float64(expected) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := uint64(math.Round(float64(m) / float64(expected) * math.Ln2))
+	k = max(k, 1)
+	return &BloomFilter[T]{bits: make([]uint64, (m+63)/64), m: m, k: k, expect: expected}
+}
+
+// Add registra key en el filtro.
+func (f *BloomFilter[T]) Add(key T) {
+	h1, h2 := bloomHashes([]byte(key))
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.count++
+}
+
+// MayContain devuelve false solo si key nunca se agregó.
+func (f *BloomFilter[T]) MayContain(key T) bool {
+	h1, h2 := bloomHashes([]byte(key))
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count devuelve cuántos elementos se agregaron (con repeticiones).
+func (f *BloomFilter[T]) Count() int { return f.count }
+
+// Saturated indica si se agregaron más elementos de los previstos, con lo que la tasa
+// real de falsos positivos ya supera la configurada y conviene reconstruir el filtro.
+func (f *BloomFilter[T]) Saturated() bool { return f.count > f.expect }
+
+// bloomHashes deriva dos hashes independientes para el doble hashing de
+// Kirsch-Mitzenmacher, que simula k funciones con solo dos.
+func bloomHashes(key []byte) (uint64, uint64) {
+	a := fnv.New64a()
+	a.Write(key)
+	b := fnv.New64()
+	b.Write(key)
+	return a.Sum64(), b.Sum64() | 1
+}
@@ -0,0 +1,156 @@
+package ds
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Codec traduce valores de tipo T a bytes y de vuelta. Lo usa la serialización binaria
+// del BST para sus claves y valores.
+type Codec[T any] struct {
+	Encode func(v T) ([]byte, error)
+	Decode func(data []byte) (T, error)
+}
+
+// StringCodec guarda los strings tal cual, sin copia intermedia ni escape.
+var StringCodec = Codec[string]{
+	Encode: func(v string) ([]byte, error) { return []byte(v), nil },
+	Decode: func(data []byte) (string, error) { return string(data), nil },
+}
+
+// bstMagic abre cada flujo serializado; lo sigue la cantidad de pares y luego cada par
+// en orden como (longitud, clave, longitud, valor) con longitudes uvarint.
+var bstMagic = [4]byte{'B', 'S', 'T', '1'}
+
+// maxEncodedItem acota la longitud declarada de una clave o valor, para que un flujo
+// dañado no provoque una reserva de memoria gigantesca.
+const maxEncodedItem = 1 << 24
+
+// ErrCorruptStream indica que el flujo leído por DecodeBST no es una serialización válida.
+var ErrCorruptStream = errors.New("ds: corrupt stream")
+
+// Encode escribe el árbol en w en orden de claves. El resultado no depende de la forma
+// del árbol, solo de su contenido.
+func (t *BST[K, V]) Encode(w io.Writer, keys Codec[K], values Codec[V]) error {
+	bw := bufio.NewWriter(w)
+	buf := append(bstMagic[:0:0], bstMagic[:]...)
+	buf = binary.AppendUvarint(buf, uint64(t.size))
+	if _, err := bw.Write(buf); err != nil {
+		return err
+	}
+	var err error
+	t.Ascend(func(key K, value V) bool {
+		buf = buf[:0]
+		if buf, err = appendEncoded(buf, key, keys); err != nil {
+			return false
+		}
+		if buf, err = appendEncoded(buf, value, values); err != nil {
+			return false
+		}
+		_, err = bw.Write(buf)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func appendEncoded[T any](buf []byte, v T, codec Codec[T]) ([]byte, error) {
+	raw, err := codec.Encode(v)
+	if err != nil {
+		return buf, err
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(raw)))
+	return append(buf, raw...), nil
+}
+
+// DecodeBST lee un árbol escrito por Encode. Como los pares llegan ordenados, el árbol
+// se arma con BuildFromSorted en tiempo lineal y queda perfectamente balanceado. Un
+// flujo desordenado según cmp se rechaza con ErrCorruptStream. La lectura usa un búfer,
+// así que puede consumir bytes de r posteriores al árbol; para leer varios árboles
+// seguidos del mismo flujo, r debe ser un *bufio.Reader compartido.
+func DecodeBST[K any, V any](r io.Reader, cmp func(a, b K) int, keys Codec[K], values Codec[V]) (*BST[K, V], error) {
+	br := bufio.NewReader(r)
+	var magic [4]byte
+	if _, err := io.ReadFull(br, magic[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptStream, err)
+	}
+	if magic != bstMagic {
+		return nil, fmt.Errorf("%w: bad magic", ErrCorruptStream)
+	}
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptStream, err)
+	}
+
+	ks := make([]K, 0, min(n, 1<<16))
+	vs := make([]V, 0, min(n, 1<<16))
+	for i := uint64(0); i < n; i++ {
+		key, err := readEncoded(br, keys)
+		if err != nil {
+			return nil, err
+		}
+		value, err := readEncoded(br, values)
+		if err != nil {
+			return nil, err
+		}
+		ks = append(ks, key)
+		vs = append(vs, value)
+	}
+	t, err := BuildFromSorted(cmp, ks, vs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptStream, err)
+	}
+	return t, nil
+}
+
+func readEncoded[T any](br *bufio.Reader, codec Codec[T]) (T, error) {
+	var zero T
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return zero, fmt.Errorf("%w: %v", ErrCorruptStream, err)
+	}
+	if size > maxEncodedItem {
+		return zero, fmt.Errorf("%w: item of %d bytes", ErrCorruptStream, size)
+	}
+	raw := make([]byte, size)
+	if _, err := io.ReadFull(br, raw); err != nil {
+		return zero, fmt.Errorf("%w: %v", ErrCorruptStream, err)
+	}
+	return codec.Decode(raw)
+}
+
+// BuildFromSorted crea un árbol AVL con las claves keys, que deben venir en orden
+// estrictamente creciente según cmp, asociadas a values por posición. Cada subárbol toma
+// la mediana de su tramo como raíz, así que el árbol queda perfectamente balanceado y
+// se construye en O(n) en lugar del O(n log n) de n inserciones.
+func BuildFromSorted[K any, V any](cmp func(a, b K) int, keys []K, values []V) (*BST[K, V], error) {
+	t := NewBST[K, V](cmp)
+	if len(keys) != len(values) {
+		return nil, fmt.Errorf("ds: %d keys but %d values", len(keys), len(values))
+	}
+	for i := 1; i < len(keys); i++ {
+		if cmp(keys[i-1], keys[i]) >= 0 {
+			return nil, fmt.Errorf("ds: keys not strictly increasing at index %d", i)
+		}
+	}
+	t.root = buildSorted(keys, values)
+	t.size = len(keys)
+	return t, nil
+}
+
+func buildSorted[K any, V any](keys []K, values []V) *bstNode[K, V] {
+	if len(keys) == 0 {
+		return nil
+	}
+	mid := len(keys) / 2
+	node := &bstNode[K, V]{key: keys[mid], value: values[mid]}
+	node.left = buildSorted(keys[:mid], values[:mid])
+	node.right = buildSorted(keys[mid+1:], values[mid+1:])
+	node.update()
+	return node
+}
@@ -0,0 +1,78 @@
+package ds
+
+// Double-ended queue over a growable circular slice: push and pop at both ends in O(1) amortized.
+
+type Deque[T any] struct {
+	data []T
+	head int
+	size int
+}
+
+func NewDeque[T any]() *Deque[T] { return &Deque[T]{data: make([]T, 8)} }
+
+func (d *Deque[T]) PushFront(v T) {
+	d.grow()
+	d.head = (d.head - 1 + len(d.data)) % len(d.data)
+	d.data[d.head] = v
+	d.size++
+}
+
+func (d *Deque[T]) PushBack(v T) {
+	d.grow()
+	d.data[(d.head+d.size)%len(d.data)] = v
+	d.size++
+}
+
+func (d *Deque[T]) PopFront() (T, bool) {
+	var zero T
+	if d.size == 0 {
+		return zero, false
+	}
+	v := d.data[d.head]
+	d.data[d.head] = zero
+	d.head = (d.head + 1) % len(d.data)
+	d.size--
+	return v, true
+}
+
+func (d *Deque[T]) PopBack() (T, bool) {
+	var zero T
+	if d.size == 0 {
+		return zero, false
+	}
+	i := (d.head + d.size - 1) % len(d.data)
+	v := d.data[i]
+	d.data[i] = zero
+	d.size--
+	return v, true
+}
+
+func (d *Deque[T]) Front() (T, bool) {
+	var zero T
+	if d.size == 0 {
+		return zero, false
+	}
+	return d.data[d.head], true
+}
+
+func (d *Deque[T]) Back() (T, bool) {
+	var zero T
+	if d.size == 0 {
+		return zero, false
+	}
+	return d.data[(d.head+d.size-1)%len(d.data)], true
+}
+
+func (d *Deque[T]) Size() int { return d.size }
+
+func (d *Deque[T]) grow() {
+	if d.size < len(d.data) {
+		return
+	}
+	data := make([]T, len(d.data)*2)
+	for i := 0; i < d.size; i++ {
+		data[i] = d.data[(d.head+i)%len(d.data)]
+	}
+	d.data = data
+	d.head = 0
+}
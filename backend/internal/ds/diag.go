@@ -0,0 +1,113 @@
+package ds
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// TreeStats resume la forma de un BST. AverageDepth es el número medio de nodos que
+// visita una búsqueda exitosa (la raíz cuenta 1), así que se compara directamente con
+// Height: en un árbol degenerado ambos crecen linealmente con Size.
+type TreeStats struct {
+	Size         int
+	Height       int
+	AverageDepth float64
+	Leaves       int
+}
+
+// Stats recorre el árbol completo y devuelve su altura, profundidad media y hojas.
+func (t *BST[K, V]) Stats() TreeStats {
+	stats := TreeStats{Size: t.size}
+	totalDepth := 0
+	var walk func(node *bstNode[K, V], depth int)
+	walk = func(node *bstNode[K, V], depth int) {
+		if node == nil {
+			return
+		}
+		totalDepth += depth
+		stats.Height = max(stats.Height, depth)
+		if node.left == nil && node.right == nil {
+			stats.Leaves++
+		}
+		walk(node.left, depth+1)
+		walk(node.right, depth+1)
+	}
+	walk(t.root, 1)
+	if t.size > 0 {
+		stats.AverageDepth = float64(totalDepth) / float64(t.size)
+	}
+	return stats
+}
+
+// Validate comprueba los invariantes del árbol: claves estrictamente ordenadas, alturas
+// y tamaños de subárbol guardados en cada nodo, el tamaño total y, en modo AVL, que
+// ningún nodo tenga un factor de balance fuera de [-1, 1]. Devuelve el primer problema
+// encontrado.
+func (t *BST[K, V]) Validate() error {
+	var previous *bstNode[K, V]
+	var check func(node *bstNode[K, V]) error
+	check = func(node *bstNode[K, V]) error {
+		if node == nil {
+			return nil
+		}
+		if err := check(node.left); err != nil {
+			return err
+		}
+		if previous != nil && t.cmp(previous.key, node.key) >= 0 {
+			return fmt.Errorf("ds: key %v out of order after %v", node.key, previous.key)
+		}
+		previous = node
+		if err := check(node.right); err != nil {
+			return err
+		}
+		if h := 1 + max(height(node.left), height(node.right)); node.height != h {
+			return fmt.Errorf("ds: node %v stores height %d, actual %d", node.key, node.height, h)
+		}
+		if c := 1 + count(node.left) + count(node.right); node.count != c {
+			return fmt.Errorf("ds: node %v stores count %d, actual %d", node.key, node.count, c)
+		}
+		if b := balanceFactor(node); t.balanced && (b > 1 || b < -1) {
+			return fmt.Errorf("ds: node %v has balance factor %d", node.key, b)
+		}
+		return nil
+	}
+	if err := check(t.root); err != nil {
+		return err
+	}
+	if c := count(t.root); t.size != c {
+		return fmt.Errorf("ds: tree stores size %d, actual %d", t.size, c)
+	}
+	return nil
+}
+
+// WriteDOT escribe el árbol en formato Graphviz. Cuando un nodo tiene un solo hijo se
+// dibuja un punto en el lugar del que falta para que se distinga izquierda de derecha.
+func (t *BST[K, V]) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph BST {")
+	fmt.Fprintln(bw, "\tnode [shape=circle];")
+	next := 0
+	var write func(node *bstNode[K, V]) int
+	write = func(node *bstNode[K, V]) int {
+		id := next
+		next++
+		if node == nil {
+			fmt.Fprintf(bw, "\tn%d [shape=point];\n", id)
+			return id
+		}
+		fmt.Fprintf(bw, "\tn%d [label=%q];\n", id, fmt.Sprint(node.key))
+		if node.left == nil && node.right == nil {
+			return id
+		}
+		for _, child := range []*bstNode[K, V]{node.left, node.right} {
+			fmt.Fprintf(bw, "\tn%d -> n%d;\n", id, write(child))
+		}
+		return id
+	}
+	if t.root != nil {
+		write(t.root)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
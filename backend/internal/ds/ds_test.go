@@ -1,6 +1,10 @@
 package ds
 
-import "testing"
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
 
 func TestStack(t *testing.T) {
 	s := NewStack[int]()
@@ -28,3 +32,221 @@ func TestArray(t *testing.T) {
 	if !a.Set(1, 9) { t.Fatalf("set") }
 	v, ok := a.Get(1); if !ok || v != 9 { t.Fatalf("get") }
 }
+
+func TestSyncOrderedMapConcurrentUpdates(t *testing.T) {
+	m := NewSyncOrderedMap[int, int](NewBST[int, int](func(a, b int) int { return a - b }))
+	var wg sync.WaitGroup
+	for g := 0; g < 50; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				m.Update(i%10, func(v int, _ bool) (int, bool) { return v + 1, true })
+				m.Get(i % 10)
+				m.Ascend(func(_, _ int) bool { return true })
+			}
+		}()
+	}
+	wg.Wait()
+	total := 0
+	m.TraverseInOrder(func(_ int, v int) { total += v })
+	if total != 5000 {
+		t.Fatalf("expected 5000 increments, got %d", total)
+	}
+}
+
+func TestRingBufferOverwritesOldest(t *testing.T) {
+	r := NewRingBuffer[int](3)
+	for i := 1; i <= 3; i++ {
+		if _, evicted := r.Push(i); evicted {
+			t.Fatalf("unexpected eviction at %d", i)
+		}
+	}
+	old, evicted := r.Push(4)
+	if !evicted || old != 1 {
+		t.Fatalf("expected eviction of 1, got %d %v", old, evicted)
+	}
+	if r.Size() != 3 || r.Cap() != 3 {
+		t.Fatalf("unexpected size/cap %d/%d", r.Size(), r.Cap())
+	}
+	if v, ok := r.Get(0); !ok || v != 2 {
+		t.Fatalf("oldest should be 2, got %d", v)
+	}
+	got := r.Newest(10)
+	if len(got) != 3 || got[0] != 4 || got[1] != 3 || got[2] != 2 {
+		t.Fatalf("unexpected newest: %v", got)
+	}
+	if got := r.Newest(1); len(got) != 1 || got[0] != 4 {
+		t.Fatalf("unexpected newest(1): %v", got)
+	}
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	evicted := make([]string, 0)
+	c := NewLRU[string, int](2, func(k string, _ int) { evicted = append(evicted, k) })
+	c.Put("a", 1)
+	c.Put("b", 2)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("get a: %d %v", v, ok)
+	}
+	if !c.Put("c", 3) {
+		t.Fatalf("expected eviction when exceeding capacity")
+	}
+	if len(evicted) != 1 || evicted[0] != "b" {
+		t.Fatalf("expected b evicted, got %v", evicted)
+	}
+	if _, ok := c.Peek("b"); ok {
+		t.Fatalf("b should be gone")
+	}
+
+	c.Put("a", 10)
+	c.Put("d", 4)
+	if _, ok := c.Peek("c"); ok || len(evicted) != 2 {
+		t.Fatalf("expected c evicted after a was refreshed, evicted=%v", evicted)
+	}
+	if !c.Remove("a") || c.Len() != 1 {
+		t.Fatalf("remove a failed, len=%d", c.Len())
+	}
+	c.Purge()
+	if c.Len() != 0 {
+		t.Fatalf("purge left %d entries", c.Len())
+	}
+	if len(evicted) != 2 {
+		t.Fatalf("remove and purge must not call onEvict: %v", evicted)
+	}
+}
+
+func TestListRemoveAndMoveByHandle(t *testing.T) {
+	l := NewList[string]()
+	b := l.InsertBack("b")
+	l.InsertFront("a")
+	c := l.InsertBack("c")
+	l.InsertBack("d")
+
+	if v, ok := l.Remove(b); !ok || v != "b" {
+		t.Fatalf("remove middle failed: %s %v", v, ok)
+	}
+	if _, ok := l.Remove(b); ok {
+		t.Fatalf("removing twice should fail")
+	}
+	if !l.MoveToFront(c) {
+		t.Fatalf("move to front failed")
+	}
+	if !l.MoveToBack(l.Front().Next) {
+		t.Fatalf("move to back failed")
+	}
+
+	forward := make([]string, 0)
+	l.ForEach(func(v string) { forward = append(forward, v) })
+	backward := make([]string, 0)
+	l.ForEachReverse(func(v string) { backward = append(backward, v) })
+	if !equalStrings(forward, []string{"c", "d", "a"}) || !equalStrings(backward, []string{"a", "d", "c"}) {
+		t.Fatalf("unexpected order: forward=%v backward=%v", forward, backward)
+	}
+	if l.Size() != 3 || l.Front().Value != "c" || l.Back().Value != "a" {
+		t.Fatalf("unexpected ends or size: %d", l.Size())
+	}
+
+	other := NewList[string]()
+	if other.MoveToFront(c) {
+		t.Fatalf("a list must reject nodes from another list")
+	}
+}
+
+func TestDequeBothEnds(t *testing.T) {
+	d := NewDeque[int]()
+	for i := 0; i < 20; i++ {
+		d.PushBack(i)
+		d.PushFront(-i - 1)
+	}
+	if d.Size() != 40 {
+		t.Fatalf("expected 40 elements, got %d", d.Size())
+	}
+	if v, ok := d.Front(); !ok || v != -20 {
+		t.Fatalf("front: %d", v)
+	}
+	if v, ok := d.Back(); !ok || v != 19 {
+		t.Fatalf("back: %d", v)
+	}
+	for want := 19; want >= 0; want-- {
+		if v, ok := d.PopBack(); !ok || v != want {
+			t.Fatalf("pop back: got %d want %d", v, want)
+		}
+	}
+	for want := -20; want < 0; want++ {
+		if v, ok := d.PopFront(); !ok || v != want {
+			t.Fatalf("pop front: got %d want %d", v, want)
+		}
+	}
+	if _, ok := d.PopFront(); ok {
+		t.Fatalf("deque should be empty")
+	}
+}
+
+func TestSkipListConcurrentReaders(t *testing.T) {
+	s := NewSkipList[int, int](func(a, b int) int { return a - b })
+	for i := 0; i < 1000; i++ {
+		s.Put(i, i)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if v, ok := s.Get(i); ok && v != i {
+					t.Errorf("get %d returned %d", i, v)
+				}
+			}
+		}()
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < 1000; i += 8 {
+				s.Put(1000+i, 1000+i)
+				s.Delete(i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if s.Size() != 1000 {
+		t.Fatalf("expected 1000 keys, got %d", s.Size())
+	}
+	if k, ok := firstKey(s); !ok || k != 1000 {
+		t.Fatalf("expected first key 1000, got %d", k)
+	}
+}
+
+func firstKey(m OrderedMap[int, int]) (int, bool) {
+	key, found := 0, false
+	m.Ascend(func(k, _ int) bool { key, found = k, true; return false })
+	return key, found
+}
+
+func TestBloomFilterFalsePositiveRate(t *testing.T) {
+	const n = 5000
+	f := NewBloomFilter[string](n, 0.01)
+	for i := 0; i < n; i++ {
+		f.Add(fmt.Sprintf("978-%d", i))
+	}
+	for i := 0; i < n; i++ {
+		if !f.MayContain(fmt.Sprintf("978-%d", i)) {
+			t.Fatalf("false negative for %d", i)
+		}
+	}
+	falsePositives := 0
+	for i := n; i < 2*n; i++ {
+		if f.MayContain(fmt.Sprintf("978-%d", i)) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / n; rate > 0.03 {
+		t.Fatalf("false positive rate %.3f is far above the configured 0.01", rate)
+	}
+	if f.Saturated() {
+		t.Fatalf("filter should not be saturated at its expected size")
+	}
+	f.Add("extra")
+	if !f.Saturated() {
+		t.Fatalf("filter should report saturation past its expected size")
+	}
+}
@@ -0,0 +1,143 @@
+package ds
+
+// Heap es un montículo binario genérico (cola de prioridad) ordenado por la función less
+// del consumidor: Pop devuelve siempre el elemento mínimo según less. Push entrega un
+// handle que permite actualizar o retirar el elemento en O(log n) sin buscarlo.
+type Heap[T any] struct {
+	items []*HeapItem[T]
+	less  func(a, b T) bool
+}
+
+// HeapItem es el handle de un elemento dentro del montículo. Deja de ser válido cuando
+// el elemento sale por Pop o Remove.
+type HeapItem[T any] struct {
+	value T
+	index int
+}
+
+// Value devuelve el valor guardado en el handle.
+func (it *HeapItem[T]) Value() T { return it.value }
+
+// NewHeap crea un montículo vacío. Igual que con los árboles, exigir el comparador
+// evita montículos que no sabrían ordenarse.
+func NewHeap[T any](less func(a, b T) bool) *Heap[T] {
+	if less == nil {
+		panic("nil comparator")
+	}
+	return &Heap[T]{less: less}
+}
+
+func (h *Heap[T]) Size() int { return len(h.items) }
+
+// Push inserta v y devuelve su handle.
+func (h *Heap[T]) Push(v T) *HeapItem[T] {
+	item := &HeapItem[T]{value: v, index: len(h.items)}
+	h.items = append(h.items, item)
+	h.up(item.index)
+	return item
+}
+
+// Peek devuelve el mínimo sin retirarlo.
+func (h *Heap[T]) Peek() (T, bool) {
+	if len(h.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return h.items[0].value, true
+}
+
+// Pop retira y devuelve el mínimo.
+func (h *Heap[T]) Pop() (T, bool) {
+	if len(h.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return h.removeAt(0), true
+}
+
+// Update reemplaza el valor del handle y restaura el orden del montículo.
+func (h *Heap[T]) Update(item *HeapItem[T], v T) bool {
+	if !h.owns(item) {
+		return false
+	}
+	item.value = v
+	h.fix(item.index)
+	return true
+}
+
+// Fix restaura el orden después de que cambió la prioridad de item por fuera (por
+// ejemplo, cuando T es un puntero y se modificó el objeto apuntado).
+func (h *Heap[T]) Fix(item *HeapItem[T]) bool {
+	if !h.owns(item) {
+		return false
+	}
+	h.fix(item.index)
+	return true
+}
+
+// Remove retira item del montículo, esté donde esté.
+func (h *Heap[T]) Remove(item *HeapItem[T]) (T, bool) {
+	if !h.owns(item) {
+		var zero T
+		return zero, false
+	}
+	return h.removeAt(item.index), true
+}
+
+func (h *Heap[T]) owns(item *HeapItem[T]) bool {
+	return item != nil && item.index >= 0 && item.index < len(h.items) && h.items[item.index] == item
+}
+
+func (h *Heap[T]) removeAt(i int) T {
+	last := len(h.items) - 1
+	item := h.items[i]
+	h.swap(i, last)
+	h.items[last] = nil
+	h.items = h.items[:last]
+	if i < last {
+		h.fix(i)
+	}
+	item.index = -1
+	return item.value
+}
+
+func (h *Heap[T]) fix(i int) {
+	if !h.down(i) {
+		h.up(i)
+	}
+}
+
+func (h *Heap[T]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !h.less(h.items[i].value, h.items[parent].value) {
+			return
+		}
+		h.swap(i, parent)
+		i = parent
+	}
+}
+
+// down hunde el elemento i y reporta si se movió.
+func (h *Heap[T]) down(i int) bool {
+	start := i
+	for {
+		smallest := i
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < len(h.items) && h.less(h.items[child].value, h.items[smallest].value) {
+				smallest = child
+			}
+		}
+		if smallest == i {
+			return i > start
+		}
+		h.swap(i, smallest)
+		i = smallest
+	}
+}
+
+func (h *Heap[T]) swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
+}
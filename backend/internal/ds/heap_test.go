@@ -0,0 +1,68 @@
+package ds
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestHeapPopsInOrder(t *testing.T) {
+	h := NewHeap[int](func(a, b int) bool { return a < b })
+	if _, ok := h.Pop(); ok {
+		t.Fatalf("pop on empty heap should fail")
+	}
+
+	rng := rand.New(rand.NewSource(7))
+	values := make([]int, 200)
+	for i := range values {
+		values[i] = rng.Intn(1000)
+		h.Push(values[i])
+	}
+	sort.Ints(values)
+
+	if v, ok := h.Peek(); !ok || v != values[0] {
+		t.Fatalf("peek: got %d want %d", v, values[0])
+	}
+	for i, want := range values {
+		v, ok := h.Pop()
+		if !ok || v != want {
+			t.Fatalf("pop %d: got %d want %d", i, v, want)
+		}
+	}
+	if h.Size() != 0 {
+		t.Fatalf("expected empty heap, got %d", h.Size())
+	}
+}
+
+func TestHeapUpdateAndRemoveByHandle(t *testing.T) {
+	h := NewHeap[string](func(a, b string) bool { return a < b })
+	handles := make(map[string]*HeapItem[string])
+	for _, v := range []string{"d", "b", "f", "a", "e"} {
+		handles[v] = h.Push(v)
+	}
+
+	if !h.Update(handles["f"], "0") {
+		t.Fatalf("update f failed")
+	}
+	if v, _ := h.Peek(); v != "0" {
+		t.Fatalf("updated item should be first, got %s", v)
+	}
+	if v, ok := h.Remove(handles["b"]); !ok || v != "b" {
+		t.Fatalf("remove b failed: %s %v", v, ok)
+	}
+	if _, ok := h.Remove(handles["b"]); ok {
+		t.Fatalf("removing twice should fail")
+	}
+	if h.Update(handles["b"], "z") {
+		t.Fatalf("updating a removed handle should fail")
+	}
+
+	got := make([]string, 0)
+	for h.Size() > 0 {
+		v, _ := h.Pop()
+		got = append(got, v)
+	}
+	if !equalStrings(got, []string{"0", "a", "d", "e"}) {
+		t.Fatalf("unexpected order: %v", got)
+	}
+}
@@ -0,0 +1,233 @@
+package ds
+
+// IntervalTree guarda intervalos semiabiertos [Start, End) con un valor asociado y
+// responde qué intervalos se superponen con un rango o contienen un punto. Es un AVL
+// ordenado por Start en el que cada nodo guarda además el mayor End de su subárbol,
+// lo que permite descartar ramas completas durante las consultas.
+type IntervalTree[T any, V any] struct {
+	root *intervalNode[T, V]
+	cmp  func(a, b T) int
+	size int
+	seq  uint64
+}
+
+// Interval es un intervalo guardado en el árbol. El puntero devuelto por Insert sirve
+// como handle para Delete.
+type Interval[T any, V any] struct {
+	Start, End T
+	Value      V
+	seq        uint64
+}
+
+type intervalNode[T any, V any] struct {
+	iv          *Interval[T, V]
+	maxEnd      T
+	height      int
+	left, right *intervalNode[T, V]
+}
+
+// NewIntervalTree crea un árbol vacío que ordena los extremos con cmp.
+func NewIntervalTree[T any, V any](cmp func(a, b T) int) *IntervalTree[T, V] {
+	if cmp == nil {
+		panic("nil comparator")
+	}
+	return &IntervalTree[T, V]{cmp: cmp}
+}
+
+func (t *IntervalTree[T, V]) Size() int { return t.size }
+
+// Insert agrega [start, end) con su valor. Intervalos vacíos o invertidos no se guardan
+// y devuelven nil.
+func (t *IntervalTree[T, V]) Insert(start, end T, value V) *Interval[T, V] {
+	if t.cmp(start, end) >= 0 {
+		return nil
+	}
+	t.seq++
+	iv := &Interval[T, V]{Start: start, End: end, Value: value, seq: t.seq}
+	t.root = t.insert(t.root, iv)
+	t.size++
+	return iv
+}
+
+func (t *IntervalTree[T, V]) insert(node *intervalNode[T, V], iv *Interval[T, V]) *intervalNode[T, V] {
+	if node == nil {
+		return &intervalNode[T, V]{iv: iv, maxEnd: iv.End, height: 1}
+	}
+	if t.less(iv, node.iv) {
+		node.left = t.insert(node.left, iv)
+	} else {
+		node.right = t.insert(node.right, iv)
+	}
+	return t.rebalance(node)
+}
+
+// Delete quita el intervalo devuelto por Insert.
+func (t *IntervalTree[T, V]) Delete(iv *Interval[T, V]) bool {
+	if iv == nil {
+		return false
+	}
+	var deleted bool
+	t.root, deleted = t.delete(t.root, iv)
+	if deleted {
+		t.size--
+	}
+	return deleted
+}
+
+func (t *IntervalTree[T, V]) delete(node *intervalNode[T, V], iv *Interval[T, V]) (*intervalNode[T, V], bool) {
+	if node == nil {
+		return nil, false
+	}
+	var deleted bool
+	switch {
+	case node.iv == iv:
+		if node.left == nil {
+			return node.right, true
+		}
+		if node.right == nil {
+			return node.left, true
+		}
+		successor := node.right
+		for successor.left != nil {
+			successor = successor.left
+		}
+		node.right, _ = t.delete(node.right, successor.iv)
+		node.iv = successor.iv
+		return t.rebalance(node), true
+	case t.less(iv, node.iv):
+		node.left, deleted = t.delete(node.left, iv)
+	default:
+		node.right, deleted = t.delete(node.right, iv)
+	}
+	if !deleted {
+		return node, false
+	}
+	return t.rebalance(node), true
+}
+
+// Overlapping recorre, ordenados por Start, los intervalos que comparten algún instante
+// con [from, to) hasta que fn devuelva false.
+func (t *IntervalTree[T, V]) Overlapping(from, to T, fn func(iv *Interval[T, V]) bool) {
+	if fn == nil || t.cmp(from, to) >= 0 {
+		return
+	}
+	t.overlapping(t.root, from, to, fn)
+}
+
+func (t *IntervalTree[T, V]) overlapping(node *intervalNode[T, V], from, to T, fn func(iv *Interval[T, V]) bool) bool {
+	// Si ningún intervalo del subárbol termina después de from, no hay nada que buscar.
+	if node == nil || t.cmp(node.maxEnd, from) <= 0 {
+		return true
+	}
+	if !t.overlapping(node.left, from, to, fn) {
+		return false
+	}
+	if t.cmp(node.iv.Start, to) >= 0 {
+		// Todo lo que queda a la derecha empieza aún más tarde.
+		return true
+	}
+	if t.cmp(node.iv.End, from) > 0 && !fn(node.iv) {
+		return false
+	}
+	return t.overlapping(node.right, from, to, fn)
+}
+
+// Stabbing recorre los intervalos que contienen el instante at (Start <= at < End).
+func (t *IntervalTree[T, V]) Stabbing(at T, fn func(iv *Interval[T, V]) bool) {
+	if fn == nil {
+		return
+	}
+	t.stabbing(t.root, at, fn)
+}
+
+func (t *IntervalTree[T, V]) stabbing(node *intervalNode[T, V], at T, fn func(iv *Interval[T, V]) bool) bool {
+	if node == nil || t.cmp(node.maxEnd, at) <= 0 {
+		return true
+	}
+	if !t.stabbing(node.left, at, fn) {
+		return false
+	}
+	if t.cmp(node.iv.Start, at) > 0 {
+		return true
+	}
+	if t.cmp(node.iv.End, at) > 0 && !fn(node.iv) {
+		return false
+	}
+	return t.stabbing(node.right, at, fn)
+}
+
+// Ascend recorre todos los intervalos ordenados por Start hasta que fn devuelva false.
+func (t *IntervalTree[T, V]) Ascend(fn func(iv *Interval[T, V]) bool) {
+	if fn == nil {
+		return
+	}
+	intervalAscend(t.root, fn)
+}
+
+func intervalAscend[T any, V any](node *intervalNode[T, V], fn func(iv *Interval[T, V]) bool) bool {
+	if node == nil {
+		return true
+	}
+	return intervalAscend(node.left, fn) && fn(node.iv) && intervalAscend(node.right, fn)
+}
+
+// less ordena por Start y desempata por orden de inserción, así intervalos con el mismo
+// inicio conviven en el árbol.
+func (t *IntervalTree[T, V]) less(a, b *Interval[T, V]) bool {
+	if c := t.cmp(a.Start, b.Start); c != 0 {
+		return c < 0
+	}
+	return a.seq < b.seq
+}
+
+func (t *IntervalTree[T, V]) update(node *intervalNode[T, V]) {
+	node.height = 1 + max(intervalHeight(node.left), intervalHeight(node.right))
+	node.maxEnd = node.iv.End
+	for _, child := range []*intervalNode[T, V]{node.left, node.right} {
+		if child != nil && t.cmp(child.maxEnd, node.maxEnd) > 0 {
+			node.maxEnd = child.maxEnd
+		}
+	}
+}
+
+func (t *IntervalTree[T, V]) rebalance(node *intervalNode[T, V]) *intervalNode[T, V] {
+	t.update(node)
+	switch factor := intervalHeight(node.left) - intervalHeight(node.right); {
+	case factor > 1:
+		if intervalHeight(node.left.left) < intervalHeight(node.left.right) {
+			node.left = t.rotateLeft(node.left)
+		}
+		return t.rotateRight(node)
+	case factor < -1:
+		if intervalHeight(node.right.right) < intervalHeight(node.right.left) {
+			node.right = t.rotateRight(node.right)
+		}
+		return t.rotateLeft(node)
+	}
+	return node
+}
+
+func (t *IntervalTree[T, V]) rotateLeft(node *intervalNode[T, V]) *intervalNode[T, V] {
+	pivot := node.right
+	node.right = pivot.left
+	pivot.left = node
+	t.update(node)
+	t.update(pivot)
+	return pivot
+}
+
+func (t *IntervalTree[T, V]) rotateRight(node *intervalNode[T, V]) *intervalNode[T, V] {
+	pivot := node.left
+	node.left = pivot.right
+	pivot.right = node
+	t.update(node)
+	t.update(pivot)
+	return pivot
+}
+
+func intervalHeight[T any, V any](node *intervalNode[T, V]) int {
+	if node == nil {
+		return 0
+	}
+	return node.height
+}
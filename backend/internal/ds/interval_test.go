@@ -0,0 +1,95 @@
+package ds
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestIntervalTreeStabbingAndOverlap(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	tree := NewIntervalTree[time.Time, string](func(a, b time.Time) int { return a.Compare(b) })
+
+	tree.Insert(day(1), day(15), "loan-a")
+	b := tree.Insert(day(10), day(20), "loan-b")
+	tree.Insert(day(20), day(25), "loan-c")
+	if tree.Insert(day(5), day(5), "empty") != nil {
+		t.Fatalf("empty interval should be rejected")
+	}
+
+	collect := func(query func(fn func(iv *Interval[time.Time, string]) bool)) []string {
+		out := make([]string, 0)
+		query(func(iv *Interval[time.Time, string]) bool { out = append(out, iv.Value); return true })
+		return out
+	}
+
+	got := collect(func(fn func(iv *Interval[time.Time, string]) bool) { tree.Stabbing(day(12), fn) })
+	if !equalStrings(got, []string{"loan-a", "loan-b"}) {
+		t.Fatalf("stabbing day 12: %v", got)
+	}
+	// Los intervalos son semiabiertos: el día 20 ya no pertenece a loan-b.
+	got = collect(func(fn func(iv *Interval[time.Time, string]) bool) { tree.Stabbing(day(20), fn) })
+	if !equalStrings(got, []string{"loan-c"}) {
+		t.Fatalf("stabbing day 20: %v", got)
+	}
+	got = collect(func(fn func(iv *Interval[time.Time, string]) bool) { tree.Overlapping(day(15), day(21), fn) })
+	if !equalStrings(got, []string{"loan-b", "loan-c"}) {
+		t.Fatalf("overlap [15, 21): %v", got)
+	}
+
+	if !tree.Delete(b) || tree.Delete(b) {
+		t.Fatalf("delete should succeed exactly once")
+	}
+	got = collect(func(fn func(iv *Interval[time.Time, string]) bool) { tree.Overlapping(day(15), day(21), fn) })
+	if !equalStrings(got, []string{"loan-c"}) || tree.Size() != 2 {
+		t.Fatalf("after delete: %v size=%d", got, tree.Size())
+	}
+}
+
+func TestIntervalTreeMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	tree := NewIntervalTree[int, int](func(a, b int) int { return a - b })
+	live := make(map[int]*Interval[int, int])
+	for i := 0; i < 2000; i++ {
+		if len(live) > 0 && rng.Intn(4) == 0 {
+			for id, iv := range live {
+				tree.Delete(iv)
+				delete(live, id)
+				break
+			}
+			continue
+		}
+		start := rng.Intn(1000)
+		live[i] = tree.Insert(start, start+1+rng.Intn(50), i)
+	}
+
+	for q := 0; q < 200; q++ {
+		from := rng.Intn(1000)
+		to := from + 1 + rng.Intn(30)
+		expected := 0
+		for _, iv := range live {
+			if iv.Start < to && iv.End > from {
+				expected++
+			}
+		}
+		got := 0
+		tree.Overlapping(from, to, func(*Interval[int, int]) bool { got++; return true })
+		if got != expected {
+			t.Fatalf("overlap [%d, %d): got %d want %d", from, to, got, expected)
+		}
+	}
+	if tree.Size() != len(live) {
+		t.Fatalf("size %d, expected %d", tree.Size(), len(live))
+	}
+	seen, lastStart := 0, -1
+	tree.Ascend(func(iv *Interval[int, int]) bool {
+		if iv.Start < lastStart {
+			t.Fatalf("Ascend out of order: %d after %d", iv.Start, lastStart)
+		}
+		seen, lastStart = seen+1, iv.Start
+		return true
+	})
+	if seen != len(live) {
+		t.Fatalf("Ascend visited %d intervals, expected %d", seen, len(live))
+	}
+}
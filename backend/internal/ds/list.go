@@ -1,23 +1,73 @@
 package ds
 
-// Singly linked list for generic type T
+// Doubly linked list for generic type T. Insert operations return the node, which works
+// as a handle for O(1) Remove and MoveToFront/MoveToBack.
 
 type ListNode[T any] struct {
 	Value T
 	Next  *ListNode[T]
+	Prev  *ListNode[T]
+	list  *List[T]
 }
 
 type List[T any] struct {
 	head *ListNode[T]
+	tail *ListNode[T]
 	size int
 }
 
 func NewList[T any]() *List[T] { return &List[T]{} }
 
-func (l *List[T]) InsertFront(v T) {
-	n := &ListNode[T]{Value: v, Next: l.head}
-	l.head = n
+func (l *List[T]) InsertFront(v T) *ListNode[T] {
+	n := &ListNode[T]{Value: v, list: l}
+	l.linkFront(n)
 	l.size++
+	return n
+}
+
+func (l *List[T]) InsertBack(v T) *ListNode[T] {
+	n := &ListNode[T]{Value: v, list: l}
+	l.linkBack(n)
+	l.size++
+	return n
+}
+
+func (l *List[T]) Front() *ListNode[T] { return l.head }
+
+func (l *List[T]) Back() *ListNode[T] { return l.tail }
+
+// Remove unlinks n from the list. It fails if n belongs to another list or was already removed.
+func (l *List[T]) Remove(n *ListNode[T]) (T, bool) {
+	if n == nil || n.list != l {
+		var zero T
+		return zero, false
+	}
+	l.unlink(n)
+	n.list = nil
+	l.size--
+	return n.Value, true
+}
+
+func (l *List[T]) MoveToFront(n *ListNode[T]) bool {
+	if n == nil || n.list != l {
+		return false
+	}
+	if n != l.head {
+		l.unlink(n)
+		l.linkFront(n)
+	}
+	return true
+}
+
+func (l *List[T]) MoveToBack(n *ListNode[T]) bool {
+	if n == nil || n.list != l {
+		return false
+	}
+	if n != l.tail {
+		l.unlink(n)
+		l.linkBack(n)
+	}
+	return true
 }
 
 func (l *List[T]) ForEach(fn func(v T)) {
@@ -26,14 +76,62 @@ func (l *List[T]) ForEach(fn func(v T)) {
 	}
 }
 
+func (l *List[T]) ForEachReverse(fn func(v T)) {
+	for n := l.tail; n != nil; n = n.Prev {
+		fn(n.Value)
+	}
+}
+
 func (l *List[T]) Find(pred func(v T) bool) (T, bool) {
+	if n := l.FindNode(pred); n != nil {
+		return n.Value, true
+	}
+	var zero T
+	return zero, false
+}
+
+// FindNode returns the first node whose value matches pred, or nil.
+func (l *List[T]) FindNode(pred func(v T) bool) *ListNode[T] {
 	for n := l.head; n != nil; n = n.Next {
 		if pred(n.Value) {
-			return n.Value, true
+			return n
 		}
 	}
-	var zero T
-	return zero, false
+	return nil
 }
 
 func (l *List[T]) Size() int { return l.size }
+
+func (l *List[T]) linkFront(n *ListNode[T]) {
+	n.Prev, n.Next = nil, l.head
+	if l.head != nil {
+		l.head.Prev = n
+	} else {
+		l.tail = n
+	}
+	l.head = n
+}
+
+func (l *List[T]) linkBack(n *ListNode[T]) {
+	n.Prev, n.Next = l.tail, nil
+	if l.tail != nil {
+		l.tail.Next = n
+	} else {
+		l.head = n
+	}
+	l.tail = n
+}
+
+func (l *List[T]) unlink(n *ListNode[T]) {
+	if n.Prev != nil {
+		n.Prev.Next = n.Next
+	} else {
+		l.head = n.Next
+	}
+	if n.Next != nil {
+		n.Next.Prev = n.Prev
+	} else {
+		l.tail = n.Prev
+	}
+	n.Prev, n.Next = nil, nil
+}
@@ -0,0 +1,92 @@
+package ds
+
+// LRU es una caché de capacidad fija que descarta el elemento usado hace más tiempo.
+// Combina un mapa (búsqueda O(1)) con una List ordenada por uso, cuyos nodos sirven de
+// handle para mover al frente y expulsar el último en O(1).
+type LRU[K comparable, V any] struct {
+	capacity int
+	entries  map[K]*ListNode[lruEntry[K, V]]
+	order    *List[lruEntry[K, V]]
+	onEvict  func(key K, value V)
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// NewLRU crea una caché con la capacidad indicada. onEvict, si no es nil, se invoca con
+// cada elemento expulsado por falta de espacio (no con Remove ni Purge).
+func NewLRU[K comparable, V any](capacity int, onEvict func(key K, value V)) *LRU[K, V] {
+	if capacity <= 0 {
+		panic("lru capacity must be positive")
+	}
+	return &LRU[K, V]{
+		capacity: capacity,
+		entries:  make(map[K]*ListNode[lruEntry[K, V]]),
+		order:    NewList[lruEntry[K, V]](),
+		onEvict:  onEvict,
+	}
+}
+
+// Get devuelve el valor de key y lo marca como el más reciente.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	n, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(n)
+	return n.Value.value, true
+}
+
+// Peek devuelve el valor de key sin alterar el orden de uso.
+func (c *LRU[K, V]) Peek(key K) (V, bool) {
+	n, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return n.Value.value, true
+}
+
+// Put guarda value bajo key como el elemento más reciente y reporta si tuvo que
+// expulsar otro para hacerle lugar.
+func (c *LRU[K, V]) Put(key K, value V) bool {
+	if n, ok := c.entries[key]; ok {
+		n.Value.value = value
+		c.order.MoveToFront(n)
+		return false
+	}
+	c.entries[key] = c.order.InsertFront(lruEntry[K, V]{key: key, value: value})
+	if len(c.entries) <= c.capacity {
+		return false
+	}
+	oldest, _ := c.order.Remove(c.order.Back())
+	delete(c.entries, oldest.key)
+	if c.onEvict != nil {
+		c.onEvict(oldest.key, oldest.value)
+	}
+	return true
+}
+
+// Remove descarta key si está en la caché.
+func (c *LRU[K, V]) Remove(key K) bool {
+	n, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	c.order.Remove(n)
+	delete(c.entries, key)
+	return true
+}
+
+// Purge vacía la caché.
+func (c *LRU[K, V]) Purge() {
+	c.entries = make(map[K]*ListNode[lruEntry[K, V]])
+	c.order = NewList[lruEntry[K, V]]()
+}
+
+func (c *LRU[K, V]) Len() int { return len(c.entries) }
+
+func (c *LRU[K, V]) Cap() int { return c.capacity }
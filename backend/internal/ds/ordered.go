@@ -0,0 +1,26 @@
+package ds
+
+// OrderedMap es el contrato común de los diccionarios ordenados del paquete. Permite
+// que los servicios elijan la estructura de respaldo (BST, AVL, rojo-negro, persistente, lista de salto) sin
+// cambiar su código.
+type OrderedMap[K any, V any] interface {
+	Put(key K, value V) (V, bool)
+	Get(key K) (V, bool)
+	Contains(key K) bool
+	Delete(key K) (V, bool)
+	Size() int
+	IsEmpty() bool
+	TraverseInOrder(fn func(key K, value V))
+	// Range, Ascend y AscendAfter recorren en orden y se detienen cuando fn devuelve false.
+	Range(from, to K, fn func(key K, value V) bool)
+	Ascend(fn func(key K, value V) bool)
+	AscendAfter(key K, fn func(key K, value V) bool)
+}
+
+var (
+	_ OrderedMap[string, int] = (*BST[string, int])(nil)
+	_ OrderedMap[string, int] = (*RBTree[string, int])(nil)
+	_ OrderedMap[string, int] = (*VersionedMap[string, int])(nil)
+	_ OrderedMap[string, int] = (*SyncOrderedMap[string, int])(nil)
+	_ OrderedMap[string, int] = (*SkipList[string, int])(nil)
+)
@@ -0,0 +1,258 @@
+package ds
+
+import "sync/atomic"
+
+// PersistentBST es una versión inmutable del árbol AVL. Put y Delete no modifican el
+// árbol original: copian solo los nodos del camino afectado y devuelven un árbol nuevo
+// que comparte el resto de los subárboles con el anterior. Cualquier versión puede
+// leerse desde varias goroutines sin sincronización.
+type PersistentBST[K any, V any] struct {
+	root *bstNode[K, V]
+	cmp  func(a, b K) int
+	size int
+}
+
+// NewPersistentBST crea una versión vacía configurada con el comparador recibido.
+func NewPersistentBST[K any, V any](cmp func(a, b K) int) *PersistentBST[K, V] {
+	if cmp == nil {
+		panic("nil comparator")
+	}
+	return &PersistentBST[K, V]{cmp: cmp}
+}
+
+// Size devuelve cuántos elementos hay en esta versión.
+func (t *PersistentBST[K, V]) Size() int { return t.size }
+
+// IsEmpty indica si esta versión no contiene elementos.
+func (t *PersistentBST[K, V]) IsEmpty() bool { return t.size == 0 }
+
+// Height devuelve la altura de esta versión.
+func (t *PersistentBST[K, V]) Height() int { return height(t.root) }
+
+// Put devuelve una versión nueva con key asociada a value, junto con el valor previo
+// si la clave ya existía.
+func (t *PersistentBST[K, V]) Put(key K, value V) (*PersistentBST[K, V], V, bool) {
+	root, previous, replaced := t.put(t.root, key, value)
+	size := t.size
+	if !replaced {
+		size++
+	}
+	return &PersistentBST[K, V]{root: root, cmp: t.cmp, size: size}, previous, replaced
+}
+
+func (t *PersistentBST[K, V]) put(node *bstNode[K, V], key K, value V) (*bstNode[K, V], V, bool) {
+	if node == nil {
+		var zero V
+		return &bstNode[K, V]{key: key, value: value, height: 1, count: 1}, zero, false
+	}
+
+	var previous V
+	var replaced bool
+	copied := cloneNode(node)
+	comparison := t.cmp(key, node.key)
+	switch {
+	case comparison < 0:
+		copied.left, previous, replaced = t.put(node.left, key, value)
+	case comparison > 0:
+		copied.right, previous, replaced = t.put(node.right, key, value)
+	default:
+		copied.value = value
+		return copied, node.value, true
+	}
+	return persistentRebalance(copied), previous, replaced
+}
+
+// Delete devuelve una versión sin key. Si la clave no existe devuelve la misma versión.
+func (t *PersistentBST[K, V]) Delete(key K) (*PersistentBST[K, V], V, bool) {
+	root, removed, deleted := t.delete(t.root, key)
+	if !deleted {
+		return t, removed, false
+	}
+	return &PersistentBST[K, V]{root: root, cmp: t.cmp, size: t.size - 1}, removed, true
+}
+
+func (t *PersistentBST[K, V]) delete(node *bstNode[K, V], key K) (*bstNode[K, V], V, bool) {
+	if node == nil {
+		var zero V
+		return nil, zero, false
+	}
+
+	comparison := t.cmp(key, node.key)
+	if comparison == 0 {
+		if node.left == nil {
+			return node.right, node.value, true
+		}
+		if node.right == nil {
+			return node.left, node.value, true
+		}
+		successor := minNode(node.right)
+		replacement := &bstNode[K, V]{
+			key:   successor.key,
+			value: successor.value,
+			left:  node.left,
+			right: persistentDeleteMin(node.right),
+		}
+		return persistentRebalance(replacement), node.value, true
+	}
+
+	child := node.left
+	if comparison > 0 {
+		child = node.right
+	}
+	newChild, removed, deleted := t.delete(child, key)
+	if !deleted {
+		return node, removed, false
+	}
+	copied := cloneNode(node)
+	if comparison < 0 {
+		copied.left = newChild
+	} else {
+		copied.right = newChild
+	}
+	return persistentRebalance(copied), removed, true
+}
+
+func (t *PersistentBST[K, V]) Get(key K) (V, bool) {
+	n := t.root
+	for n != nil {
+		cmp := t.cmp(key, n.key)
+		if cmp == 0 {
+			return n.value, true
+		}
+		if cmp < 0 {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+func (t *PersistentBST[K, V]) Contains(key K) bool {
+	_, ok := t.Get(key)
+	return ok
+}
+
+func (t *PersistentBST[K, V]) TraverseInOrder(fn func(key K, value V)) {
+	if fn == nil {
+		return
+	}
+	traverseInOrder(t.root, fn)
+}
+
+// Range recorre en orden las claves del intervalo [from, to) hasta que fn devuelva false.
+func (t *PersistentBST[K, V]) Range(from, to K, fn func(key K, value V) bool) {
+	if fn == nil {
+		return
+	}
+	rangeNodes(t.root, from, to, t.cmp, fn)
+}
+
+// Ascend recorre esta versión en orden hasta que fn devuelva false.
+func (t *PersistentBST[K, V]) Ascend(fn func(key K, value V) bool) {
+	if fn == nil {
+		return
+	}
+	newCursor(t.root).drain(fn)
+}
+
+// AscendAfter recorre en orden las claves mayores que key hasta que fn devuelva false.
+func (t *PersistentBST[K, V]) AscendAfter(key K, fn func(key K, value V) bool) {
+	if fn == nil {
+		return
+	}
+	newCursorAfter(t.root, key, t.cmp).drain(fn)
+}
+
+func cloneNode[K any, V any](node *bstNode[K, V]) *bstNode[K, V] {
+	copied := *node
+	return &copied
+}
+
+func persistentDeleteMin[K any, V any](node *bstNode[K, V]) *bstNode[K, V] {
+	if node.left == nil {
+		return node.right
+	}
+	copied := cloneNode(node)
+	copied.left = persistentDeleteMin(node.left)
+	return persistentRebalance(copied)
+}
+
+// persistentRebalance es el rebalanceo AVL para nodos ya copiados: antes de rotar
+// copia también los hijos que la rotación va a modificar, porque pueden estar
+// compartidos con versiones anteriores.
+func persistentRebalance[K any, V any](node *bstNode[K, V]) *bstNode[K, V] {
+	node.update()
+	switch factor := balanceFactor(node); {
+	case factor > 1:
+		node.left = cloneNode(node.left)
+		if balanceFactor(node.left) < 0 {
+			node.left.right = cloneNode(node.left.right)
+			node.left = rotateLeft(node.left)
+		}
+		return rotateRight(node)
+	case factor < -1:
+		node.right = cloneNode(node.right)
+		if balanceFactor(node.right) > 0 {
+			node.right.left = cloneNode(node.right.left)
+			node.right = rotateRight(node.right)
+		}
+		return rotateLeft(node)
+	}
+	return node
+}
+
+// VersionedMap adapta PersistentBST a la interfaz OrderedMap. Cada escritura publica
+// una versión nueva de forma atómica, así que los lectores (Snapshot, Get, recorridos)
+// nunca bloquean ni ven estados intermedios. Las escrituras deben serializarse por
+// fuera: dos Put simultáneos pueden perder uno de los cambios.
+type VersionedMap[K any, V any] struct {
+	current atomic.Pointer[PersistentBST[K, V]]
+}
+
+// NewVersionedMap crea un mapa versionado vacío.
+func NewVersionedMap[K any, V any](cmp func(a, b K) int) *VersionedMap[K, V] {
+	m := &VersionedMap[K, V]{}
+	m.current.Store(NewPersistentBST[K, V](cmp))
+	return m
+}
+
+// Snapshot devuelve la versión vigente. Sigue siendo válida e inmutable aunque el
+// mapa reciba escrituras después.
+func (m *VersionedMap[K, V]) Snapshot() *PersistentBST[K, V] { return m.current.Load() }
+
+func (m *VersionedMap[K, V]) Put(key K, value V) (V, bool) {
+	next, previous, replaced := m.current.Load().Put(key, value)
+	m.current.Store(next)
+	return previous, replaced
+}
+
+func (m *VersionedMap[K, V]) Delete(key K) (V, bool) {
+	next, removed, deleted := m.current.Load().Delete(key)
+	if deleted {
+		m.current.Store(next)
+	}
+	return removed, deleted
+}
+
+func (m *VersionedMap[K, V]) Get(key K) (V, bool) { return m.Snapshot().Get(key) }
+func (m *VersionedMap[K, V]) Contains(key K) bool { return m.Snapshot().Contains(key) }
+func (m *VersionedMap[K, V]) Size() int           { return m.Snapshot().Size() }
+func (m *VersionedMap[K, V]) IsEmpty() bool       { return m.Snapshot().IsEmpty() }
+
+func (m *VersionedMap[K, V]) TraverseInOrder(fn func(key K, value V)) {
+	m.Snapshot().TraverseInOrder(fn)
+}
+
+func (m *VersionedMap[K, V]) Range(from, to K, fn func(key K, value V) bool) {
+	m.Snapshot().Range(from, to, fn)
+}
+
+func (m *VersionedMap[K, V]) Ascend(fn func(key K, value V) bool) {
+	m.Snapshot().Ascend(fn)
+}
+
+func (m *VersionedMap[K, V]) AscendAfter(key K, fn func(key K, value V) bool) {
+	m.Snapshot().AscendAfter(key, fn)
+}
@@ -0,0 +1,76 @@
+package ds
+
+import "testing"
+
+func TestPersistentBSTKeepsOldVersions(t *testing.T) {
+	v0 := NewPersistentBST[int, string](func(a, b int) int { return a - b })
+	versions := []*PersistentBST[int, string]{v0}
+	for i := 0; i < 256; i++ {
+		next, _, _ := versions[len(versions)-1].Put(i, "x")
+		versions = append(versions, next)
+	}
+
+	for n, v := range versions {
+		if v.Size() != n {
+			t.Fatalf("version %d: expected size %d, got %d", n, n, v.Size())
+		}
+		if n > 0 && !v.Contains(n-1) {
+			t.Fatalf("version %d should contain %d", n, n-1)
+		}
+		if v.Contains(n) {
+			t.Fatalf("version %d should not contain %d yet", n, n)
+		}
+		checkAVL(t, v.root)
+	}
+	if h := versions[256].Height(); h > 12 {
+		t.Fatalf("expected logarithmic height, got %d", h)
+	}
+
+	full := versions[256]
+	replaced, prev, ok := full.Put(10, "y")
+	if !ok || prev != "x" {
+		t.Fatalf("expected replace of 10, got ok=%v prev=%q", ok, prev)
+	}
+	if v, _ := full.Get(10); v != "x" {
+		t.Fatalf("old version changed after replace: %q", v)
+	}
+	if v, _ := replaced.Get(10); v != "y" {
+		t.Fatalf("new version missing replace: %q", v)
+	}
+
+	trimmed := full
+	for i := 0; i < 256; i += 2 {
+		trimmed, _, _ = trimmed.Delete(i)
+	}
+	checkAVL(t, trimmed.root)
+	if trimmed.Size() != 128 || full.Size() != 256 {
+		t.Fatalf("unexpected sizes: trimmed=%d full=%d", trimmed.Size(), full.Size())
+	}
+	count := 0
+	full.TraverseInOrder(func(k int, _ string) {
+		if k != count {
+			t.Fatalf("old version lost key %d", count)
+		}
+		count++
+	})
+	if same, _, ok := trimmed.Delete(0); ok || same != trimmed {
+		t.Fatalf("deleting a missing key should return the same version")
+	}
+}
+
+func TestVersionedMapSnapshotIsStable(t *testing.T) {
+	m := NewVersionedMap[string, int](stringsCompare)
+	m.Put("a", 1)
+	m.Put("b", 2)
+	snap := m.Snapshot()
+
+	m.Put("c", 3)
+	m.Delete("a")
+
+	if snap.Size() != 2 || !snap.Contains("a") || snap.Contains("c") {
+		t.Fatalf("snapshot changed after writes")
+	}
+	if m.Size() != 2 || m.Contains("a") || !m.Contains("c") {
+		t.Fatalf("map did not apply writes")
+	}
+}
@@ -0,0 +1,304 @@
+package ds
+
+// RBTree es un árbol rojo-negro inclinado a la izquierda (LLRB, Sedgewick). Ofrece las
+// mismas operaciones que BST con garantía de altura logarítmica y menos rotaciones que
+// un AVL en cargas con muchas inserciones y eliminaciones.
+type RBTree[K any, V any] struct {
+	root *rbNode[K, V]
+	cmp  func(a, b K) int
+	size int
+}
+
+const (
+	red   = true
+	black = false
+)
+
+// rbNode guarda el color del enlace que llega desde su padre: rojo indica que el nodo
+// forma, junto con el padre, un nodo 3 del árbol 2-3 equivalente.
+type rbNode[K any, V any] struct {
+	key         K
+	value       V
+	color       bool
+	left, right *rbNode[K, V]
+}
+
+// NewRBTree crea un árbol rojo-negro vacío con el comparador recibido.
+func NewRBTree[K any, V any](cmp func(a, b K) int) *RBTree[K, V] {
+	if cmp == nil {
+		panic("nil comparator")
+	}
+	return &RBTree[K, V]{cmp: cmp}
+}
+
+// Size devuelve cuántos elementos viven actualmente en el árbol.
+func (t *RBTree[K, V]) Size() int { return t.size }
+
+// IsEmpty indica si el árbol no contiene elementos.
+func (t *RBTree[K, V]) IsEmpty() bool { return t.size == 0 }
+
+// Height devuelve la altura del árbol contando enlaces rojos y negros.
+func (t *RBTree[K, V]) Height() int { return rbHeight(t.root) }
+
+func (t *RBTree[K, V]) Put(key K, value V) (V, bool) {
+	var previous V
+	var replaced bool
+	t.root, previous, replaced = t.put(t.root, key, value)
+	t.root.color = black
+	if !replaced {
+		t.size++
+	}
+	return previous, replaced
+}
+
+func (t *RBTree[K, V]) put(h *rbNode[K, V], key K, value V) (*rbNode[K, V], V, bool) {
+	if h == nil {
+		var zero V
+		return &rbNode[K, V]{key: key, value: value, color: red}, zero, false
+	}
+
+	var previous V
+	var replaced bool
+	comparison := t.cmp(key, h.key)
+	switch {
+	case comparison < 0:
+		h.left, previous, replaced = t.put(h.left, key, value)
+	case comparison > 0:
+		h.right, previous, replaced = t.put(h.right, key, value)
+	default:
+		previous = h.value
+		h.value = value
+		return h, previous, true
+	}
+	return rbBalance(h), previous, replaced
+}
+
+func (t *RBTree[K, V]) Get(key K) (V, bool) {
+	n := t.root
+	for n != nil {
+		cmp := t.cmp(key, n.key)
+		if cmp == 0 {
+			return n.value, true
+		}
+		if cmp < 0 {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+func (t *RBTree[K, V]) Contains(key K) bool {
+	_, ok := t.Get(key)
+	return ok
+}
+
+func (t *RBTree[K, V]) Delete(key K) (V, bool) {
+	removed, ok := t.Get(key)
+	if !ok {
+		return removed, false
+	}
+	if !isRed(t.root.left) && !isRed(t.root.right) {
+		t.root.color = red
+	}
+	t.root = t.delete(t.root, key)
+	if t.root != nil {
+		t.root.color = black
+	}
+	t.size--
+	return removed, true
+}
+
+// delete asume que la clave existe; Delete lo verifica antes de descender.
+func (t *RBTree[K, V]) delete(h *rbNode[K, V], key K) *rbNode[K, V] {
+	if t.cmp(key, h.key) < 0 {
+		if !isRed(h.left) && !isRed(h.left.left) {
+			h = rbMoveRedLeft(h)
+		}
+		h.left = t.delete(h.left, key)
+		return rbBalance(h)
+	}
+
+	if isRed(h.left) {
+		h = rbRotateRight(h)
+	}
+	if t.cmp(key, h.key) == 0 && h.right == nil {
+		return nil
+	}
+	if !isRed(h.right) && !isRed(h.right.left) {
+		h = rbMoveRedRight(h)
+	}
+	if t.cmp(key, h.key) == 0 {
+		successor := h.right
+		for successor.left != nil {
+			successor = successor.left
+		}
+		h.key, h.value = successor.key, successor.value
+		h.right = rbDeleteMin(h.right)
+	} else {
+		h.right = t.delete(h.right, key)
+	}
+	return rbBalance(h)
+}
+
+func (t *RBTree[K, V]) TraverseInOrder(fn func(key K, value V)) {
+	if fn == nil {
+		return
+	}
+	rbTraverseInOrder(t.root, fn)
+}
+
+func rbTraverseInOrder[K any, V any](node *rbNode[K, V], fn func(key K, value V)) {
+	if node == nil {
+		return
+	}
+	rbTraverseInOrder(node.left, fn)
+	fn(node.key, node.value)
+	rbTraverseInOrder(node.right, fn)
+}
+
+func rbDeleteMin[K any, V any](h *rbNode[K, V]) *rbNode[K, V] {
+	if h.left == nil {
+		return nil
+	}
+	if !isRed(h.left) && !isRed(h.left.left) {
+		h = rbMoveRedLeft(h)
+	}
+	h.left = rbDeleteMin(h.left)
+	return rbBalance(h)
+}
+
+func isRed[K any, V any](node *rbNode[K, V]) bool {
+	return node != nil && node.color == red
+}
+
+func rbHeight[K any, V any](node *rbNode[K, V]) int {
+	if node == nil {
+		return 0
+	}
+	return 1 + max(rbHeight(node.left), rbHeight(node.right))
+}
+
+func rbRotateLeft[K any, V any](h *rbNode[K, V]) *rbNode[K, V] {
+	x := h.right
+	h.right = x.left
+	x.left = h
+	x.color = h.color
+	h.color = red
+	return x
+}
+
+func rbRotateRight[K any, V any](h *rbNode[K, V]) *rbNode[K, V] {
+	x := h.left
+	h.left = x.right
+	x.right = h
+	x.color = h.color
+	h.color = red
+	return x
+}
+
+func rbFlipColors[K any, V any](h *rbNode[K, V]) {
+	h.color = !h.color
+	h.left.color = !h.left.color
+	h.right.color = !h.right.color
+}
+
+// rbMoveRedLeft toma prestado un enlace rojo del hermano derecho para que el
+// descenso por la izquierda nunca termine en un nodo 2.
+func rbMoveRedLeft[K any, V any](h *rbNode[K, V]) *rbNode[K, V] {
+	rbFlipColors(h)
+	if isRed(h.right.left) {
+		h.right = rbRotateRight(h.right)
+		h = rbRotateLeft(h)
+		rbFlipColors(h)
+	}
+	return h
+}
+
+func rbMoveRedRight[K any, V any](h *rbNode[K, V]) *rbNode[K, V] {
+	rbFlipColors(h)
+	if isRed(h.left.left) {
+		h = rbRotateRight(h)
+		rbFlipColors(h)
+	}
+	return h
+}
+
+// rbBalance restaura los invariantes LLRB al subir por el camino de inserción o borrado.
+func rbBalance[K any, V any](h *rbNode[K, V]) *rbNode[K, V] {
+	if isRed(h.right) && !isRed(h.left) {
+		h = rbRotateLeft(h)
+	}
+	if isRed(h.left) && isRed(h.left.left) {
+		h = rbRotateRight(h)
+	}
+	if isRed(h.left) && isRed(h.right) {
+		rbFlipColors(h)
+	}
+	return h
+}
+
+// Range recorre en orden las claves del intervalo [from, to) hasta que fn devuelva false.
+func (t *RBTree[K, V]) Range(from, to K, fn func(key K, value V) bool) {
+	if fn == nil {
+		return
+	}
+	rbRange(t.root, from, to, t.cmp, fn)
+}
+
+func rbRange[K any, V any](node *rbNode[K, V], from, to K, cmp func(a, b K) int, fn func(key K, value V) bool) bool {
+	if node == nil {
+		return true
+	}
+	afterFrom := cmp(from, node.key) <= 0
+	beforeTo := cmp(to, node.key) > 0
+	if afterFrom && !rbRange(node.left, from, to, cmp, fn) {
+		return false
+	}
+	if afterFrom && beforeTo && !fn(node.key, node.value) {
+		return false
+	}
+	if beforeTo {
+		return rbRange(node.right, from, to, cmp, fn)
+	}
+	return true
+}
+
+// Ascend recorre el árbol en orden hasta que fn devuelva false.
+func (t *RBTree[K, V]) Ascend(fn func(key K, value V) bool) {
+	if fn == nil {
+		return
+	}
+	rbAscend(t.root, fn)
+}
+
+func rbAscend[K any, V any](node *rbNode[K, V], fn func(key K, value V) bool) bool {
+	if node == nil {
+		return true
+	}
+	return rbAscend(node.left, fn) && fn(node.key, node.value) && rbAscend(node.right, fn)
+}
+
+// AscendAfter recorre en orden las claves estrictamente mayores que key hasta que fn
+// devuelva false.
+func (t *RBTree[K, V]) AscendAfter(key K, fn func(key K, value V) bool) {
+	if fn == nil {
+		return
+	}
+	rbAscendAfter(t.root, key, t.cmp, fn)
+}
+
+func rbAscendAfter[K any, V any](node *rbNode[K, V], key K, cmp func(a, b K) int, fn func(key K, value V) bool) bool {
+	if node == nil {
+		return true
+	}
+	if cmp(key, node.key) < 0 {
+		if !rbAscendAfter(node.left, key, cmp, fn) || !fn(node.key, node.value) {
+			return false
+		}
+		return rbAscend(node.right, fn)
+	}
+	return rbAscendAfter(node.right, key, cmp, fn)
+}
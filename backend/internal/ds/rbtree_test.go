@@ -0,0 +1,126 @@
+package ds
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestOrderedMapImplementationsAgree(t *testing.T) {
+	intCmp := func(a, b int) int { return a - b }
+	impls := map[string]OrderedMap[int, int]{
+		"bst":       NewUnbalancedBST[int, int](intCmp),
+		"avl":       NewBST[int, int](intCmp),
+		"red-black": NewRBTree[int, int](intCmp),
+		"versioned": NewVersionedMap[int, int](intCmp),
+		"skiplist":  NewSkipList[int, int](intCmp),
+	}
+
+	for name, m := range impls {
+		t.Run(name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(42))
+			want := make(map[int]int)
+			for i := 0; i < 5000; i++ {
+				k := rng.Intn(500)
+				if rng.Intn(3) == 0 {
+					prev, ok := m.Delete(k)
+					exp, expOK := want[k]
+					if ok != expOK || prev != exp {
+						t.Fatalf("delete %d: got (%d,%v) want (%d,%v)", k, prev, ok, exp, expOK)
+					}
+					delete(want, k)
+					continue
+				}
+				prev, replaced := m.Put(k, i)
+				exp, expOK := want[k]
+				if replaced != expOK || prev != exp {
+					t.Fatalf("put %d: got (%d,%v) want (%d,%v)", k, prev, replaced, exp, expOK)
+				}
+				want[k] = i
+			}
+
+			from, to := 100, 200
+			expected := 0
+			for k := range want {
+				if k >= from && k < to {
+					expected++
+				}
+			}
+			inRange := 0
+			m.Range(from, to, func(k, _ int) bool {
+				if k < from || k >= to {
+					t.Fatalf("range yielded %d outside [%d, %d)", k, from, to)
+				}
+				inRange++
+				return true
+			})
+			if inRange != expected {
+				t.Fatalf("range yielded %d keys, expected %d", inRange, expected)
+			}
+			m.AscendAfter(from, func(k, _ int) bool {
+				if k <= from {
+					t.Fatalf("ascend after %d yielded %d", from, k)
+				}
+				return false
+			})
+
+			if m.Size() != len(want) {
+				t.Fatalf("expected size %d, got %d", len(want), m.Size())
+			}
+			prev := -1
+			m.TraverseInOrder(func(k, v int) {
+				if k <= prev {
+					t.Fatalf("keys out of order: %d after %d", k, prev)
+				}
+				if want[k] != v {
+					t.Fatalf("key %d: got %d want %d", k, v, want[k])
+				}
+				prev = k
+			})
+		})
+	}
+}
+
+func TestRBTreeInvariantsWithSortedInserts(t *testing.T) {
+	const n = 1024
+	rb := NewRBTree[int, int](func(a, b int) int { return a - b })
+	for i := 0; i < n; i++ {
+		rb.Put(i, i)
+	}
+	checkLLRB(t, rb.root)
+	// Un rojo-negro con n nodos tiene altura a lo sumo 2*log2(n+1).
+	if h := rb.Height(); h > 20 {
+		t.Fatalf("expected logarithmic height, got %d", h)
+	}
+
+	for i := 0; i < n; i += 3 {
+		rb.Delete(i)
+	}
+	checkLLRB(t, rb.root)
+	if rb.Contains(3) || !rb.Contains(4) {
+		t.Fatalf("unexpected membership after deletes")
+	}
+}
+
+// checkLLRB verifica que no haya enlaces rojos a la derecha ni dos rojos seguidos, y
+// que todos los caminos tengan la misma cantidad de enlaces negros.
+func checkLLRB[K any, V any](t *testing.T, node *rbNode[K, V]) int {
+	t.Helper()
+	if node == nil {
+		return 0
+	}
+	if isRed(node.right) {
+		t.Fatalf("right-leaning red link")
+	}
+	if isRed(node) && isRed(node.left) {
+		t.Fatalf("two consecutive red links")
+	}
+	left := checkLLRB(t, node.left)
+	right := checkLLRB(t, node.right)
+	if left != right {
+		t.Fatalf("black height mismatch: %d vs %d", left, right)
+	}
+	if isRed(node) {
+		return left
+	}
+	return left + 1
+}
@@ -0,0 +1,55 @@
+package ds
+
+// RingBuffer es un búfer circular de capacidad fija. Cuando está lleno, cada Push
+// sobrescribe el elemento más antiguo, así que la memoria usada nunca crece.
+type RingBuffer[T any] struct {
+	data  []T
+	start int
+	size  int
+}
+
+// NewRingBuffer crea un búfer vacío con la capacidad indicada.
+func NewRingBuffer[T any](capacity int) *RingBuffer[T] {
+	if capacity <= 0 {
+		panic("ring buffer capacity must be positive")
+	}
+	return &RingBuffer[T]{data: make([]T, capacity)}
+}
+
+// Push agrega v como elemento más reciente. Si el búfer estaba lleno devuelve el
+// elemento más antiguo, que se descartó.
+func (r *RingBuffer[T]) Push(v T) (T, bool) {
+	var evicted T
+	if r.size < len(r.data) {
+		r.data[(r.start+r.size)%len(r.data)] = v
+		r.size++
+		return evicted, false
+	}
+	evicted = r.data[r.start]
+	r.data[r.start] = v
+	r.start = (r.start + 1) % len(r.data)
+	return evicted, true
+}
+
+// Get devuelve el i-ésimo elemento contando desde el más antiguo.
+func (r *RingBuffer[T]) Get(i int) (T, bool) {
+	var zero T
+	if i < 0 || i >= r.size {
+		return zero, false
+	}
+	return r.data[(r.start+i)%len(r.data)], true
+}
+
+// Newest devuelve hasta n elementos empezando por el más reciente.
+func (r *RingBuffer[T]) Newest(n int) []T {
+	n = min(max(n, 0), r.size)
+	out := make([]T, n)
+	for i := 0; i < n; i++ {
+		out[i], _ = r.Get(r.size - 1 - i)
+	}
+	return out
+}
+
+func (r *RingBuffer[T]) Size() int { return r.size }
+
+func (r *RingBuffer[T]) Cap() int { return len(r.data) }
@@ -0,0 +1,116 @@
+package ds
+
+// Set es un conjunto ordenado respaldado por un árbol AVL. Add, Remove y Has cuestan
+// O(log n); Union, Intersection y Difference recorren ambos conjuntos en orden a la vez
+// y arman el resultado con BuildFromSorted, así que cuestan O(n + m).
+type Set[T any] struct {
+	tree *BST[T, struct{}]
+}
+
+// NewSet crea un conjunto vacío ordenado con cmp.
+func NewSet[T any](cmp func(a, b T) int) *Set[T] {
+	return &Set[T]{tree: NewBST[T, struct{}](cmp)}
+}
+
+// NewSetFromSorted crea un conjunto con items, que deben venir en orden estrictamente
+// creciente según cmp, en tiempo lineal.
+func NewSetFromSorted[T any](cmp func(a, b T) int, items []T) (*Set[T], error) {
+	tree, err := BuildFromSorted(cmp, items, make([]struct{}, len(items)))
+	if err != nil {
+		return nil, err
+	}
+	return &Set[T]{tree: tree}, nil
+}
+
+func (s *Set[T]) Size() int { return s.tree.Size() }
+
+// Add agrega v y devuelve false si ya estaba.
+func (s *Set[T]) Add(v T) bool {
+	_, existed := s.tree.Put(v, struct{}{})
+	return !existed
+}
+
+// Remove quita v y devuelve false si no estaba.
+func (s *Set[T]) Remove(v T) bool {
+	_, removed := s.tree.Delete(v)
+	return removed
+}
+
+func (s *Set[T]) Has(v T) bool { return s.tree.Contains(v) }
+
+// Ascend recorre los elementos en orden hasta que fn devuelva false.
+func (s *Set[T]) Ascend(fn func(v T) bool) {
+	if fn == nil {
+		return
+	}
+	s.tree.Ascend(func(v T, _ struct{}) bool { return fn(v) })
+}
+
+// Items devuelve los elementos en orden.
+func (s *Set[T]) Items() []T {
+	out := make([]T, 0, s.Size())
+	s.Ascend(func(v T) bool {
+		out = append(out, v)
+		return true
+	})
+	return out
+}
+
+// Union devuelve un conjunto nuevo con los elementos de s o de other.
+func (s *Set[T]) Union(other *Set[T]) *Set[T] {
+	return s.merge(other, true, true, true)
+}
+
+// Intersection devuelve un conjunto nuevo con los elementos de s que también están en other.
+func (s *Set[T]) Intersection(other *Set[T]) *Set[T] {
+	return s.merge(other, false, true, false)
+}
+
+// Difference devuelve un conjunto nuevo con los elementos de s que no están en other.
+func (s *Set[T]) Difference(other *Set[T]) *Set[T] {
+	return s.merge(other, true, false, false)
+}
+
+// merge avanza un cursor sobre cada conjunto como en la mezcla de mergesort. Los flags
+// indican qué conservar: elementos solo de s, de ambos y solo de other. Ambos conjuntos
+// deben usar el mismo comparador; se usa el de s.
+func (s *Set[T]) merge(other *Set[T], onlyLeft, both, onlyRight bool) *Set[T] {
+	cmp := s.tree.cmp
+	out := make([]T, 0)
+	left, right := s.tree.Cursor(), other.tree.Cursor()
+	a, _, okA := left.Next()
+	b, _, okB := right.Next()
+	for okA || okB {
+		var c int
+		switch {
+		case !okB:
+			c = -1
+		case !okA:
+			c = 1
+		default:
+			c = cmp(a, b)
+		}
+		switch {
+		case c < 0:
+			if onlyLeft {
+				out = append(out, a)
+			}
+			a, _, okA = left.Next()
+		case c > 0:
+			if onlyRight {
+				out = append(out, b)
+			}
+			b, _, okB = right.Next()
+		default:
+			if both {
+				out = append(out, a)
+			}
+			a, _, okA = left.Next()
+			b, _, okB = right.Next()
+		}
+	}
+	tree := NewBST[T, struct{}](cmp)
+	tree.root = buildSorted(out, make([]struct{}, len(out)))
+	tree.size = len(out)
+	return &Set[T]{tree: tree}
+}
@@ -0,0 +1,51 @@
+package ds
+
+import "testing"
+
+func TestSetBasics(t *testing.T) {
+	s := NewSet[string](stringsCompare)
+	if !s.Add("b") || !s.Add("a") || s.Add("a") {
+		t.Fatalf("Add should report only new elements")
+	}
+	if !s.Has("a") || s.Has("c") || s.Size() != 2 {
+		t.Fatalf("unexpected contents: %v", s.Items())
+	}
+	if !s.Remove("a") || s.Remove("a") {
+		t.Fatalf("Remove should succeed exactly once")
+	}
+	if !equalStrings(s.Items(), []string{"b"}) {
+		t.Fatalf("unexpected items: %v", s.Items())
+	}
+	if _, err := NewSetFromSorted(stringsCompare, []string{"b", "a"}); err == nil {
+		t.Fatalf("unsorted input should be rejected")
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	a, _ := NewSetFromSorted(stringsCompare, []string{"b1", "b2", "b3", "b5"})
+	b, _ := NewSetFromSorted(stringsCompare, []string{"b2", "b4", "b5", "b6"})
+	empty := NewSet[string](stringsCompare)
+
+	if got := a.Union(b).Items(); !equalStrings(got, []string{"b1", "b2", "b3", "b4", "b5", "b6"}) {
+		t.Fatalf("union: %v", got)
+	}
+	if got := a.Intersection(b).Items(); !equalStrings(got, []string{"b2", "b5"}) {
+		t.Fatalf("intersection: %v", got)
+	}
+	if got := a.Difference(b).Items(); !equalStrings(got, []string{"b1", "b3"}) {
+		t.Fatalf("difference: %v", got)
+	}
+	if got := a.Intersection(empty).Size(); got != 0 {
+		t.Fatalf("intersection with empty set has %d items", got)
+	}
+	if got := empty.Union(b).Items(); !equalStrings(got, b.Items()) {
+		t.Fatalf("union with empty set: %v", got)
+	}
+
+	// El resultado es un conjunto independiente y balanceado.
+	u := a.Union(b)
+	u.Add("b0")
+	if a.Has("b0") || u.tree.Validate() != nil {
+		t.Fatalf("union should not share nodes with its inputs")
+	}
+}
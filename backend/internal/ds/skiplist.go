@@ -0,0 +1,209 @@
+package ds
+
+import (
+	"math/rand"
+	"sync"
+)
+
+const (
+	skipMaxLevel = 32
+	// skipP es la probabilidad de que un nodo suba un nivel más; con 1/4 cada nivel tiene
+	// en promedio la cuarta parte de los nodos del anterior.
+	skipP = 0.25
+)
+
+// SkipList es un diccionario ordenado basado en listas de salto: cada nodo aparece en
+// una cantidad aleatoria de niveles y las búsquedas bajan de nivel en nivel, con costo
+// O(log n) esperado. A diferencia de los árboles, trae su propio RWMutex: varios
+// lectores pueden consultarla en paralelo mientras las escrituras son exclusivas. Los
+// callbacks de los recorridos corren con el candado de lectura tomado y no deben
+// escribir en la misma lista.
+type SkipList[K any, V any] struct {
+	mu    sync.RWMutex
+	head  *skipNode[K, V]
+	level int
+	size  int
+	cmp   func(a, b K) int
+	rng   *rand.Rand
+}
+
+type skipNode[K any, V any] struct {
+	key   K
+	value V
+	next  []*skipNode[K, V]
+}
+
+// NewSkipList crea una lista de salto vacía con el comparador recibido.
+func NewSkipList[K any, V any](cmp func(a, b K) int) *SkipList[K, V] {
+	if cmp == nil {
+		panic("nil comparator")
+	}
+	return &SkipList[K, V]{
+		head:  &skipNode[K, V]{next: make([]*skipNode[K, V], skipMaxLevel)},
+		level: 1,
+		cmp:   cmp,
+		rng:   rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+func (s *SkipList[K, V]) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.size
+}
+
+func (s *SkipList[K, V]) IsEmpty() bool { return s.Size() == 0 }
+
+func (s *SkipList[K, V]) Put(key K, value V) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var update [skipMaxLevel]*skipNode[K, V]
+	n := s.findPredecessors(key, &update)
+	if n != nil && s.cmp(key, n.key) == 0 {
+		previous := n.value
+		n.value = value
+		return previous, true
+	}
+
+	level := s.randomLevel()
+	if level > s.level {
+		for i := s.level; i < level; i++ {
+			update[i] = s.head
+		}
+		s.level = level
+	}
+	node := &skipNode[K, V]{key: key, value: value, next: make([]*skipNode[K, V], level)}
+	for i := 0; i < level; i++ {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+	s.size++
+	var zero V
+	return zero, false
+}
+
+func (s *SkipList[K, V]) Get(key K) (V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := s.seek(key)
+	if n != nil && s.cmp(key, n.key) == 0 {
+		return n.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+func (s *SkipList[K, V]) Contains(key K) bool {
+	_, ok := s.Get(key)
+	return ok
+}
+
+func (s *SkipList[K, V]) Delete(key K) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var update [skipMaxLevel]*skipNode[K, V]
+	n := s.findPredecessors(key, &update)
+	if n == nil || s.cmp(key, n.key) != 0 {
+		var zero V
+		return zero, false
+	}
+	for i := 0; i < len(n.next); i++ {
+		update[i].next[i] = n.next[i]
+	}
+	for s.level > 1 && s.head.next[s.level-1] == nil {
+		s.level--
+	}
+	s.size--
+	return n.value, true
+}
+
+func (s *SkipList[K, V]) TraverseInOrder(fn func(key K, value V)) {
+	if fn == nil {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for n := s.head.next[0]; n != nil; n = n.next[0] {
+		fn(n.key, n.value)
+	}
+}
+
+// Range recorre en orden las claves del intervalo [from, to) hasta que fn devuelva false.
+func (s *SkipList[K, V]) Range(from, to K, fn func(key K, value V) bool) {
+	if fn == nil {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for n := s.seek(from); n != nil && s.cmp(to, n.key) > 0; n = n.next[0] {
+		if !fn(n.key, n.value) {
+			return
+		}
+	}
+}
+
+// Ascend recorre la lista en orden hasta que fn devuelva false.
+func (s *SkipList[K, V]) Ascend(fn func(key K, value V) bool) {
+	if fn == nil {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for n := s.head.next[0]; n != nil; n = n.next[0] {
+		if !fn(n.key, n.value) {
+			return
+		}
+	}
+}
+
+// AscendAfter recorre en orden las claves mayores que key hasta que fn devuelva false.
+func (s *SkipList[K, V]) AscendAfter(key K, fn func(key K, value V) bool) {
+	if fn == nil {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := s.seek(key)
+	if n != nil && s.cmp(key, n.key) == 0 {
+		n = n.next[0]
+	}
+	for ; n != nil; n = n.next[0] {
+		if !fn(n.key, n.value) {
+			return
+		}
+	}
+}
+
+// seek devuelve el primer nodo con clave mayor o igual a key.
+func (s *SkipList[K, V]) seek(key K) *skipNode[K, V] {
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && s.cmp(x.next[i].key, key) < 0 {
+			x = x.next[i]
+		}
+	}
+	return x.next[0]
+}
+
+// findPredecessors es seek, pero además anota en update el último nodo visitado en cada
+// nivel, que es donde hay que enlazar o desenlazar.
+func (s *SkipList[K, V]) findPredecessors(key K, update *[skipMaxLevel]*skipNode[K, V]) *skipNode[K, V] {
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && s.cmp(x.next[i].key, key) < 0 {
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+	return x.next[0]
+}
+
+func (s *SkipList[K, V]) randomLevel() int {
+	level := 1
+	for level < skipMaxLevel && s.rng.Float64() < skipP {
+		level++
+	}
+	return level
+}
@@ -0,0 +1,99 @@
+package ds
+
+import "sync"
+
+// Las estructuras del paquete no son seguras para uso concurrente: quien las comparta
+// entre goroutines debe sincronizarlas. SyncOrderedMap cubre el caso habitual de un
+// diccionario ordenado con muchos lectores y pocos escritores.
+
+// SyncOrderedMap envuelve cualquier OrderedMap con un RWMutex. Las lecturas (Get,
+// recorridos) se ejecutan en paralelo; las escrituras son exclusivas. Los callbacks de
+// los recorridos corren con el candado de lectura tomado, así que no deben escribir en
+// el mismo mapa.
+type SyncOrderedMap[K any, V any] struct {
+	mu    sync.RWMutex
+	inner OrderedMap[K, V]
+}
+
+// NewSyncOrderedMap sincroniza inner. A partir de aquí inner solo debe usarse a través
+// del envoltorio.
+func NewSyncOrderedMap[K any, V any](inner OrderedMap[K, V]) *SyncOrderedMap[K, V] {
+	if inner == nil {
+		panic("nil map")
+	}
+	return &SyncOrderedMap[K, V]{inner: inner}
+}
+
+func (m *SyncOrderedMap[K, V]) Put(key K, value V) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inner.Put(key, value)
+}
+
+func (m *SyncOrderedMap[K, V]) Delete(key K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inner.Delete(key)
+}
+
+// Update aplica fn al valor actual de key (ok indica si existía) de forma atómica. Si
+// fn devuelve keep=false la clave se elimina; si no, se guarda el valor devuelto.
+func (m *SyncOrderedMap[K, V]) Update(key K, fn func(value V, ok bool) (V, bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, ok := m.inner.Get(key)
+	next, keep := fn(current, ok)
+	if keep {
+		m.inner.Put(key, next)
+	} else if ok {
+		m.inner.Delete(key)
+	}
+}
+
+func (m *SyncOrderedMap[K, V]) Get(key K) (V, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.inner.Get(key)
+}
+
+func (m *SyncOrderedMap[K, V]) Contains(key K) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.inner.Contains(key)
+}
+
+func (m *SyncOrderedMap[K, V]) Size() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.inner.Size()
+}
+
+func (m *SyncOrderedMap[K, V]) IsEmpty() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.inner.IsEmpty()
+}
+
+func (m *SyncOrderedMap[K, V]) TraverseInOrder(fn func(key K, value V)) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.inner.TraverseInOrder(fn)
+}
+
+func (m *SyncOrderedMap[K, V]) Range(from, to K, fn func(key K, value V) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.inner.Range(from, to, fn)
+}
+
+func (m *SyncOrderedMap[K, V]) Ascend(fn func(key K, value V) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.inner.Ascend(fn)
+}
+
+func (m *SyncOrderedMap[K, V]) AscendAfter(key K, fn func(key K, value V) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.inner.AscendAfter(key, fn)
+}
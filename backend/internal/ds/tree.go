@@ -2,24 +2,39 @@ package ds
 
 // BST representa un árbol de búsqueda binaria genérico. Se alimenta con un comparador
 // provisto por el consumidor para decidir cómo ordenar las claves de tipo K y así
-// asociarlas a valores de tipo V.
+// asociarlas a valores de tipo V. En modo AVL (el modo por defecto) el árbol se
+// rebalancea con rotaciones tras cada inserción y eliminación, de modo que la altura
+// se mantiene logarítmica aunque las claves lleguen ordenadas.
 type BST[K any, V any] struct {
-	root *bstNode[K, V]
-	cmp  func(a, b K) int
-	size int
+	root     *bstNode[K, V]
+	cmp      func(a, b K) int
+	size     int
+	balanced bool
 }
 
-// bstNode almacena un par clave/valor junto con referencias a sus hijos.
-// Es interna a la implementación del árbol y no se exporta.
+// bstNode almacena un par clave/valor junto con referencias a sus hijos, la altura
+// del subárbol que encabeza y cuántos nodos contiene (para Rank y Select). Es interna
+// a la implementación del árbol y no se exporta.
 type bstNode[K any, V any] struct {
 	key         K
 	value       V
+	height      int
+	count       int
 	left, right *bstNode[K, V]
 }
 
-// NewBST crea un árbol vacío configurado con el comparador recibido. Si olvidamos pasar
-// el comparador, fallamos de inmediato para evitar árboles inconsistentes.
+// NewBST crea un árbol AVL vacío configurado con el comparador recibido. Si olvidamos
+// pasar el comparador, fallamos de inmediato para evitar árboles inconsistentes.
 func NewBST[K any, V any](cmp func(a, b K) int) *BST[K, V] {
+	if cmp == nil {
+		panic("nil comparator")
+	}
+	return &BST[K, V]{cmp: cmp, balanced: true}
+}
+
+// NewUnbalancedBST crea un árbol sin rebalanceo. Con claves ordenadas degenera en una
+// lista; se conserva para comparar contra el modo AVL.
+func NewUnbalancedBST[K any, V any](cmp func(a, b K) int) *BST[K, V] {
 	if cmp == nil {
 		panic("nil comparator")
 	}
@@ -33,40 +48,39 @@ func (t *BST[K, V]) Size() int { return t.size }
 // Esta función es útil para determinar rápidamente si el árbol contiene elementos.
 func (t *BST[K, V]) IsEmpty() bool { return t.size == 0 }
 
+// Height devuelve la altura del árbol: 0 si está vacío, 1 si solo tiene raíz.
+func (t *BST[K, V]) Height() int { return height(t.root) }
+
 func (t *BST[K, V]) Put(key K, value V) (V, bool) {
-	if t.root == nil {
-		t.root = &bstNode[K, V]{key: key, value: value}
+	var previous V
+	var replaced bool
+	t.root, previous, replaced = t.put(t.root, key, value)
+	if !replaced {
 		t.size++
-		var zero V
-		return zero, false
 	}
+	return previous, replaced
+}
 
-	current := t.root
-	var zero V
-	for {
-		comparison := t.cmp(key, current.key)
-		if comparison == 0 {
-			previous := current.value
-			current.value = value
-			return previous, true
-		}
-		if comparison < 0 {
-			if current.left == nil {
-				current.left = &bstNode[K, V]{key: key, value: value}
-				t.size++
-				return zero, false
-			}
-			current = current.left
-			continue
-		}
+func (t *BST[K, V]) put(node *bstNode[K, V], key K, value V) (*bstNode[K, V], V, bool) {
+	if node == nil {
+		var zero V
+		return &bstNode[K, V]{key: key, value: value, height: 1, count: 1}, zero, false
+	}
 
-		if current.right == nil {
-			current.right = &bstNode[K, V]{key: key, value: value}
-			t.size++
-			return zero, false
-		}
-		current = current.right
+	var previous V
+	var replaced bool
+	comparison := t.cmp(key, node.key)
+	switch {
+	case comparison < 0:
+		node.left, previous, replaced = t.put(node.left, key, value)
+	case comparison > 0:
+		node.right, previous, replaced = t.put(node.right, key, value)
+	default:
+		previous = node.value
+		node.value = value
+		return node, previous, true
 	}
+	return t.rebalance(node), previous, replaced
 }
 
 func (t *BST[K, V]) Get(key K) (V, bool) {
@@ -94,31 +108,29 @@ func (t *BST[K, V]) Contains(key K) bool {
 func (t *BST[K, V]) Delete(key K) (V, bool) {
 	var removed V
 	var deleted bool
-	t.root, removed, deleted = deleteNode(t.root, key, t.cmp)
+	t.root, removed, deleted = t.delete(t.root, key)
 	if deleted {
 		t.size--
 	}
 	return removed, deleted
 }
 
-func deleteNode[K any, V any](node *bstNode[K, V], key K, cmp func(a, b K) int) (*bstNode[K, V], V, bool) {
+func (t *BST[K, V]) delete(node *bstNode[K, V], key K) (*bstNode[K, V], V, bool) {
 	if node == nil {
 		var zero V
 		return nil, zero, false
 	}
 
-	comparison := cmp(key, node.key)
+	var removed V
+	var deleted bool
+	comparison := t.cmp(key, node.key)
 	switch {
 	case comparison < 0:
-		newLeft, value, deleted := deleteNode(node.left, key, cmp)
-		node.left = newLeft
-		return node, value, deleted
+		node.left, removed, deleted = t.delete(node.left, key)
 	case comparison > 0:
-		newRight, value, deleted := deleteNode(node.right, key, cmp)
-		node.right = newRight
-		return node, value, deleted
+		node.right, removed, deleted = t.delete(node.right, key)
 	default:
-		removed := node.value
+		removed = node.value
 		if node.left == nil {
 			return node.right, removed, true
 		}
@@ -130,10 +142,14 @@ func deleteNode[K any, V any](node *bstNode[K, V], key K, cmp func(a, b K) int)
 			key:   successor.key,
 			value: successor.value,
 			left:  node.left,
-			right: deleteMin(node.right),
+			right: t.deleteMin(node.right),
 		}
-		return replacement, removed, true
+		return t.rebalance(replacement), removed, true
+	}
+	if !deleted {
+		return node, removed, false
 	}
+	return t.rebalance(node), removed, true
 }
 
 func minNode[K any, V any](node *bstNode[K, V]) *bstNode[K, V] {
@@ -143,14 +159,186 @@ func minNode[K any, V any](node *bstNode[K, V]) *bstNode[K, V] {
 	return node
 }
 
-func deleteMin[K any, V any](node *bstNode[K, V]) *bstNode[K, V] {
+func (t *BST[K, V]) deleteMin(node *bstNode[K, V]) *bstNode[K, V] {
 	if node.left == nil {
 		return node.right
 	}
-	node.left = deleteMin(node.left)
+	node.left = t.deleteMin(node.left)
+	return t.rebalance(node)
+}
+
+// rebalance recalcula la altura del nodo y, en modo AVL, aplica la rotación simple o
+// doble que corresponda cuando el factor de balance sale del rango [-1, 1].
+func (t *BST[K, V]) rebalance(node *bstNode[K, V]) *bstNode[K, V] {
+	node.update()
+	if !t.balanced {
+		return node
+	}
+	switch factor := balanceFactor(node); {
+	case factor > 1:
+		if balanceFactor(node.left) < 0 {
+			node.left = rotateLeft(node.left)
+		}
+		return rotateRight(node)
+	case factor < -1:
+		if balanceFactor(node.right) > 0 {
+			node.right = rotateRight(node.right)
+		}
+		return rotateLeft(node)
+	}
 	return node
 }
 
+func (n *bstNode[K, V]) update() {
+	n.height = 1 + max(height(n.left), height(n.right))
+	n.count = 1 + count(n.left) + count(n.right)
+}
+
+func height[K any, V any](node *bstNode[K, V]) int {
+	if node == nil {
+		return 0
+	}
+	return node.height
+}
+
+func count[K any, V any](node *bstNode[K, V]) int {
+	if node == nil {
+		return 0
+	}
+	return node.count
+}
+
+func balanceFactor[K any, V any](node *bstNode[K, V]) int {
+	return height(node.left) - height(node.right)
+}
+
+func rotateLeft[K any, V any](node *bstNode[K, V]) *bstNode[K, V] {
+	pivot := node.right
+	node.right = pivot.left
+	pivot.left = node
+	node.update()
+	pivot.update()
+	return pivot
+}
+
+func rotateRight[K any, V any](node *bstNode[K, V]) *bstNode[K, V] {
+	pivot := node.left
+	node.left = pivot.right
+	pivot.right = node
+	node.update()
+	pivot.update()
+	return pivot
+}
+
+// Min devuelve la clave más pequeña del árbol y su valor.
+func (t *BST[K, V]) Min() (K, V, bool) {
+	if t.root == nil {
+		return zeroEntry[K, V]()
+	}
+	n := minNode(t.root)
+	return n.key, n.value, true
+}
+
+// Max devuelve la clave más grande del árbol y su valor.
+func (t *BST[K, V]) Max() (K, V, bool) {
+	if t.root == nil {
+		return zeroEntry[K, V]()
+	}
+	n := t.root
+	for n.right != nil {
+		n = n.right
+	}
+	return n.key, n.value, true
+}
+
+// Floor devuelve la mayor clave menor o igual a key.
+func (t *BST[K, V]) Floor(key K) (K, V, bool) {
+	var best *bstNode[K, V]
+	for n := t.root; n != nil; {
+		comparison := t.cmp(key, n.key)
+		if comparison == 0 {
+			return n.key, n.value, true
+		}
+		if comparison < 0 {
+			n = n.left
+		} else {
+			best = n
+			n = n.right
+		}
+	}
+	if best == nil {
+		return zeroEntry[K, V]()
+	}
+	return best.key, best.value, true
+}
+
+// Ceiling devuelve la menor clave mayor o igual a key.
+func (t *BST[K, V]) Ceiling(key K) (K, V, bool) {
+	var best *bstNode[K, V]
+	for n := t.root; n != nil; {
+		comparison := t.cmp(key, n.key)
+		if comparison == 0 {
+			return n.key, n.value, true
+		}
+		if comparison > 0 {
+			n = n.right
+		} else {
+			best = n
+			n = n.left
+		}
+	}
+	if best == nil {
+		return zeroEntry[K, V]()
+	}
+	return best.key, best.value, true
+}
+
+// Rank cuenta cuántas claves del árbol son estrictamente menores que key. Si key está
+// presente, coincide con su posición (desde 0) en el recorrido en orden.
+func (t *BST[K, V]) Rank(key K) int {
+	rank := 0
+	for n := t.root; n != nil; {
+		comparison := t.cmp(key, n.key)
+		if comparison == 0 {
+			return rank + count(n.left)
+		}
+		if comparison < 0 {
+			n = n.left
+		} else {
+			rank += count(n.left) + 1
+			n = n.right
+		}
+	}
+	return rank
+}
+
+// Select devuelve la k-ésima clave en orden (desde 0). Falla si k está fuera de rango.
+func (t *BST[K, V]) Select(k int) (K, V, bool) {
+	if k < 0 || k >= t.size {
+		return zeroEntry[K, V]()
+	}
+	n := t.root
+	for n != nil {
+		left := count(n.left)
+		if k == left {
+			return n.key, n.value, true
+		}
+		if k < left {
+			n = n.left
+		} else {
+			k -= left + 1
+			n = n.right
+		}
+	}
+	return zeroEntry[K, V]()
+}
+
+func zeroEntry[K any, V any]() (K, V, bool) {
+	var key K
+	var value V
+	return key, value, false
+}
+
 func (t *BST[K, V]) TraverseInOrder(fn func(key K, value V)) {
 	if fn == nil {
 		return
@@ -166,3 +354,106 @@ func traverseInOrder[K any, V any](node *bstNode[K, V], fn func(key K, value V))
 	fn(node.key, node.value)
 	traverseInOrder(node.right, fn)
 }
+
+// Range recorre en orden las claves del intervalo [from, to) y se detiene en cuanto fn
+// devuelve false. Solo visita los subárboles que pueden contener claves del intervalo.
+func (t *BST[K, V]) Range(from, to K, fn func(key K, value V) bool) {
+	if fn == nil {
+		return
+	}
+	rangeNodes(t.root, from, to, t.cmp, fn)
+}
+
+func rangeNodes[K any, V any](node *bstNode[K, V], from, to K, cmp func(a, b K) int, fn func(key K, value V) bool) bool {
+	if node == nil {
+		return true
+	}
+	afterFrom := cmp(from, node.key) <= 0
+	beforeTo := cmp(to, node.key) > 0
+	if afterFrom && !rangeNodes(node.left, from, to, cmp, fn) {
+		return false
+	}
+	if afterFrom && beforeTo && !fn(node.key, node.value) {
+		return false
+	}
+	if beforeTo {
+		return rangeNodes(node.right, from, to, cmp, fn)
+	}
+	return true
+}
+
+// Ascend recorre el árbol en orden desde la clave más pequeña hasta que fn devuelva false.
+func (t *BST[K, V]) Ascend(fn func(key K, value V) bool) {
+	if fn == nil {
+		return
+	}
+	t.Cursor().drain(fn)
+}
+
+// AscendAfter recorre en orden las claves estrictamente mayores que key hasta que fn
+// devuelva false. Sirve para reanudar un recorrido paginado desde la última clave vista.
+func (t *BST[K, V]) AscendAfter(key K, fn func(key K, value V) bool) {
+	if fn == nil {
+		return
+	}
+	t.CursorAfter(key).drain(fn)
+}
+
+// Cursor avanza en orden sobre un BST usando una pila con el camino pendiente, así que
+// cada paso cuesta O(1) amortizado y O(altura) de memoria. Modificar el árbol mientras
+// se usa un cursor deja su recorrido indefinido; basta con crear otro con CursorAfter
+// desde la última clave leída.
+type Cursor[K any, V any] struct {
+	pending *Stack[*bstNode[K, V]]
+}
+
+// Cursor crea un cursor posicionado antes de la clave más pequeña.
+func (t *BST[K, V]) Cursor() *Cursor[K, V] { return newCursor(t.root) }
+
+// CursorAfter crea un cursor cuya primera clave es la menor estrictamente mayor que key.
+func (t *BST[K, V]) CursorAfter(key K) *Cursor[K, V] { return newCursorAfter(t.root, key, t.cmp) }
+
+func newCursor[K any, V any](root *bstNode[K, V]) *Cursor[K, V] {
+	c := &Cursor[K, V]{pending: NewStack[*bstNode[K, V]]()}
+	c.pushLeft(root)
+	return c
+}
+
+func newCursorAfter[K any, V any](root *bstNode[K, V], key K, cmp func(a, b K) int) *Cursor[K, V] {
+	c := &Cursor[K, V]{pending: NewStack[*bstNode[K, V]]()}
+	for n := root; n != nil; {
+		if cmp(key, n.key) < 0 {
+			c.pending.Push(n)
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return c
+}
+
+// Next devuelve el siguiente par en orden, o false cuando no quedan elementos.
+func (c *Cursor[K, V]) Next() (K, V, bool) {
+	n, ok := c.pending.Pop()
+	if !ok {
+		return zeroEntry[K, V]()
+	}
+	c.pushLeft(n.right)
+	return n.key, n.value, true
+}
+
+// drain entrega los pares restantes a fn hasta agotarlos o hasta que fn devuelva false.
+func (c *Cursor[K, V]) drain(fn func(key K, value V) bool) {
+	for {
+		k, v, ok := c.Next()
+		if !ok || !fn(k, v) {
+			return
+		}
+	}
+}
+
+func (c *Cursor[K, V]) pushLeft(n *bstNode[K, V]) {
+	for ; n != nil; n = n.left {
+		c.pending.Push(n)
+	}
+}
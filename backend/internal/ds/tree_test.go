@@ -1,7 +1,11 @@
 package ds
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -70,6 +74,264 @@ func TestBSTDeleteNodeWithTwoChildren(t *testing.T) {
 	}
 }
 
+func TestBSTStaysBalancedWithSortedInserts(t *testing.T) {
+	const n = 1024
+	bst := NewBST[int, int](func(a, b int) int { return a - b })
+	plain := NewUnbalancedBST[int, int](func(a, b int) int { return a - b })
+	for i := 0; i < n; i++ {
+		bst.Put(i, i)
+		plain.Put(i, i)
+	}
+
+	// Un AVL con n nodos nunca supera ~1.44*log2(n+2).
+	if h := bst.Height(); h > 15 {
+		t.Fatalf("expected logarithmic height for %d sorted keys, got %d", n, h)
+	}
+	if h := plain.Height(); h != n {
+		t.Fatalf("unbalanced tree should degenerate to height %d, got %d", n, h)
+	}
+	checkAVL(t, bst.root)
+
+	for i := 0; i < n; i += 2 {
+		if _, ok := bst.Delete(i); !ok {
+			t.Fatalf("delete %d failed", i)
+		}
+	}
+	if bst.Size() != n/2 {
+		t.Fatalf("expected size %d, got %d", n/2, bst.Size())
+	}
+	if h := bst.Height(); h > 14 {
+		t.Fatalf("expected logarithmic height after deletes, got %d", h)
+	}
+	checkAVL(t, bst.root)
+
+	prev := -1
+	bst.TraverseInOrder(func(k int, _ int) {
+		if k <= prev || k%2 == 0 {
+			t.Fatalf("unexpected key %d after %d", k, prev)
+		}
+		prev = k
+	})
+}
+
+func TestBSTOrderedQueries(t *testing.T) {
+	for name, bst := range map[string]*BST[int, string]{
+		"avl":        NewBST[int, string](func(a, b int) int { return a - b }),
+		"unbalanced": NewUnbalancedBST[int, string](func(a, b int) int { return a - b }),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, ok := bst.Min(); ok {
+				t.Fatalf("Min on empty tree should fail")
+			}
+			for _, k := range []int{50, 30, 70, 20, 40, 60, 80} {
+				bst.Put(k, fmt.Sprintf("v%d", k))
+			}
+
+			if k, _, ok := bst.Min(); !ok || k != 20 {
+				t.Fatalf("expected min 20, got %d", k)
+			}
+			if k, v, ok := bst.Max(); !ok || k != 80 || v != "v80" {
+				t.Fatalf("expected max 80, got %d %s", k, v)
+			}
+			if k, _, ok := bst.Floor(45); !ok || k != 40 {
+				t.Fatalf("expected floor(45)=40, got %d ok=%v", k, ok)
+			}
+			if k, _, ok := bst.Floor(60); !ok || k != 60 {
+				t.Fatalf("expected floor(60)=60, got %d ok=%v", k, ok)
+			}
+			if _, _, ok := bst.Floor(10); ok {
+				t.Fatalf("floor below min should fail")
+			}
+			if k, _, ok := bst.Ceiling(45); !ok || k != 50 {
+				t.Fatalf("expected ceiling(45)=50, got %d ok=%v", k, ok)
+			}
+			if _, _, ok := bst.Ceiling(81); ok {
+				t.Fatalf("ceiling above max should fail")
+			}
+
+			if r := bst.Rank(20); r != 0 {
+				t.Fatalf("expected rank(20)=0, got %d", r)
+			}
+			if r := bst.Rank(55); r != 4 {
+				t.Fatalf("expected rank(55)=4, got %d", r)
+			}
+			if r := bst.Rank(100); r != 7 {
+				t.Fatalf("expected rank(100)=7, got %d", r)
+			}
+			if k, _, ok := bst.Select(3); !ok || k != 50 {
+				t.Fatalf("expected select(3)=50, got %d ok=%v", k, ok)
+			}
+			if _, _, ok := bst.Select(7); ok {
+				t.Fatalf("select out of range should fail")
+			}
+
+			bst.Delete(50)
+			bst.Delete(20)
+			for i, want := range []int{30, 40, 60, 70, 80} {
+				if k, _, ok := bst.Select(i); !ok || k != want {
+					t.Fatalf("after deletes select(%d)=%d, want %d", i, k, want)
+				}
+				if r := bst.Rank(want); r != i {
+					t.Fatalf("after deletes rank(%d)=%d, want %d", want, r, i)
+				}
+			}
+		})
+	}
+}
+
+func TestBSTRangeAndCursor(t *testing.T) {
+	bst := NewBST[int, int](func(a, b int) int { return a - b })
+	for i := 0; i < 100; i += 5 {
+		bst.Put(i, i*10)
+	}
+
+	got := make([]int, 0)
+	bst.Range(12, 40, func(k, _ int) bool { got = append(got, k); return true })
+	if !equalInts(got, []int{15, 20, 25, 30, 35}) {
+		t.Fatalf("unexpected range: %v", got)
+	}
+
+	got = got[:0]
+	bst.Range(0, 100, func(k, _ int) bool { got = append(got, k); return len(got) < 3 })
+	if !equalInts(got, []int{0, 5, 10}) {
+		t.Fatalf("range should stop early: %v", got)
+	}
+
+	c := bst.CursorAfter(42)
+	for _, want := range []int{45, 50, 55} {
+		k, v, ok := c.Next()
+		if !ok || k != want || v != want*10 {
+			t.Fatalf("cursor: got %d/%d ok=%v want %d", k, v, ok, want)
+		}
+	}
+	if _, _, ok := bst.CursorAfter(95).Next(); ok {
+		t.Fatalf("cursor after max should be exhausted")
+	}
+
+	got = got[:0]
+	bst.AscendAfter(85, func(k, _ int) bool { got = append(got, k); return true })
+	if !equalInts(got, []int{90, 95}) {
+		t.Fatalf("unexpected ascend after: %v", got)
+	}
+
+	n := 0
+	for c := bst.Cursor(); ; n++ {
+		if _, _, ok := c.Next(); !ok {
+			break
+		}
+	}
+	if n != bst.Size() {
+		t.Fatalf("full cursor visited %d of %d keys", n, bst.Size())
+	}
+}
+
+func TestBSTBuildFromSortedAndCodec(t *testing.T) {
+	keys := make([]string, 0, 100)
+	values := make([]int, 0, 100)
+	for i := 0; i < 100; i++ {
+		keys = append(keys, fmt.Sprintf("k%03d", i))
+		values = append(values, i)
+	}
+	tree, err := BuildFromSorted(stringsCompare, keys, values)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	checkAVL(t, tree.root)
+	if tree.Size() != 100 || tree.Height() != 7 {
+		t.Fatalf("size=%d height=%d, expected 100 and 7", tree.Size(), tree.Height())
+	}
+	if _, _, ok := tree.Select(42); !ok || tree.Rank("k042") != 42 {
+		t.Fatalf("subtree counts not maintained")
+	}
+	if _, err := BuildFromSorted(stringsCompare, []string{"b", "a"}, []int{1, 2}); err == nil {
+		t.Fatalf("unsorted keys should be rejected")
+	}
+
+	intCodec := Codec[int]{
+		Encode: func(v int) ([]byte, error) { return []byte(strconv.Itoa(v)), nil },
+		Decode: func(data []byte) (int, error) { return strconv.Atoi(string(data)) },
+	}
+	var buf bytes.Buffer
+	if err := tree.Encode(&buf, StringCodec, intCodec); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	decoded, err := DecodeBST(&buf, stringsCompare, StringCodec, intCodec)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	checkAVL(t, decoded.root)
+	got := make([]string, 0)
+	decoded.TraverseInOrder(func(k string, v int) { got = append(got, fmt.Sprintf("%s=%d", k, v)) })
+	if len(got) != 100 || got[0] != "k000=0" || got[99] != "k099=99" {
+		t.Fatalf("round trip lost data: %d entries", len(got))
+	}
+
+	if _, err := DecodeBST(bytes.NewReader([]byte("BST1\x05\x01a")), stringsCompare, StringCodec, intCodec); !errors.Is(err, ErrCorruptStream) {
+		t.Fatalf("truncated stream: %v", err)
+	}
+}
+
+func TestBSTDiagnostics(t *testing.T) {
+	tree := NewUnbalancedBST[int, string](func(a, b int) int { return a - b })
+	for _, k := range []int{4, 2, 6, 1, 3, 7} {
+		tree.Put(k, "")
+	}
+	stats := tree.Stats()
+	// Profundidades: 4 -> 1; 2, 6 -> 2; 1, 3, 7 -> 3.
+	if stats.Size != 6 || stats.Height != 3 || stats.Leaves != 3 || stats.AverageDepth != 14.0/6 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("valid tree rejected: %v", err)
+	}
+
+	var dot bytes.Buffer
+	if err := tree.WriteDOT(&dot); err != nil {
+		t.Fatalf("write dot: %v", err)
+	}
+	if out := dot.String(); !strings.HasPrefix(out, "digraph BST {") || strings.Count(out, "->") != 6 || strings.Count(out, "shape=point") != 1 {
+		t.Fatalf("unexpected dot output:\n%s", out)
+	}
+
+	tree.root.left.key = 5
+	if err := tree.Validate(); err == nil {
+		t.Fatalf("out-of-order key not detected")
+	}
+	tree.root.left.key = 2
+	tree.size++
+	if err := tree.Validate(); err == nil {
+		t.Fatalf("wrong size not detected")
+	}
+
+	sorted := NewUnbalancedBST[int, string](func(a, b int) int { return a - b })
+	for k := 0; k < 10; k++ {
+		sorted.Put(k, "")
+	}
+	if stats := sorted.Stats(); stats.Height != 10 || stats.AverageDepth != 5.5 || stats.Leaves != 1 {
+		t.Fatalf("degenerate tree stats: %+v", stats)
+	}
+}
+
+func checkAVL[K any, V any](t *testing.T, node *bstNode[K, V]) int {
+	t.Helper()
+	if node == nil {
+		return 0
+	}
+	left := checkAVL(t, node.left)
+	right := checkAVL(t, node.right)
+	if left-right > 1 || right-left > 1 {
+		t.Fatalf("unbalanced node: left height %d, right height %d", left, right)
+	}
+	h := 1 + max(left, right)
+	if node.height != h {
+		t.Fatalf("stored height %d, expected %d", node.height, h)
+	}
+	if c := 1 + count(node.left) + count(node.right); node.count != c {
+		t.Fatalf("stored count %d, expected %d", node.count, c)
+	}
+	return h
+}
+
 func stringsCompare(a, b string) int {
 	if a == b {
 		return 0
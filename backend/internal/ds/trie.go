@@ -0,0 +1,155 @@
+package ds
+
+import "sort"
+
+// Trie es un árbol de prefijos sobre claves string que asocia un valor de tipo V a cada
+// clave. Los hijos de cada nodo se guardan ordenados por runa, de modo que los
+// recorridos por prefijo devuelven las claves en orden lexicográfico.
+type Trie[V any] struct {
+	root *trieNode[V]
+	size int
+}
+
+type trieNode[V any] struct {
+	children []trieEdge[V]
+	value    V
+	terminal bool
+}
+
+type trieEdge[V any] struct {
+	r    rune
+	node *trieNode[V]
+}
+
+// NewTrie crea un trie vacío.
+func NewTrie[V any]() *Trie[V] { return &Trie[V]{root: &trieNode[V]{}} }
+
+// Size devuelve cuántas claves contiene el trie.
+func (t *Trie[V]) Size() int { return t.size }
+
+// Put asocia value a key y devuelve el valor previo si la clave ya existía.
+func (t *Trie[V]) Put(key string, value V) (V, bool) {
+	n := t.root
+	for _, r := range key {
+		n = n.child(r, true)
+	}
+	previous, existed := n.value, n.terminal
+	n.value = value
+	n.terminal = true
+	if !existed {
+		t.size++
+	}
+	return previous, existed
+}
+
+func (t *Trie[V]) Get(key string) (V, bool) {
+	n := t.find(key)
+	if n == nil || !n.terminal {
+		var zero V
+		return zero, false
+	}
+	return n.value, true
+}
+
+// Delete elimina key y poda los nodos que quedan sin claves debajo.
+func (t *Trie[V]) Delete(key string) (V, bool) {
+	removed, deleted := trieDelete(t.root, []rune(key))
+	if deleted {
+		t.size--
+	}
+	return removed, deleted
+}
+
+func trieDelete[V any](n *trieNode[V], key []rune) (V, bool) {
+	var zero V
+	if len(key) == 0 {
+		if !n.terminal {
+			return zero, false
+		}
+		removed := n.value
+		n.value = zero
+		n.terminal = false
+		return removed, true
+	}
+	child := n.child(key[0], false)
+	if child == nil {
+		return zero, false
+	}
+	removed, deleted := trieDelete(child, key[1:])
+	if deleted && !child.terminal && len(child.children) == 0 {
+		n.removeChild(key[0])
+	}
+	return removed, deleted
+}
+
+// WalkPrefix recorre en orden lexicográfico las claves que empiezan con prefix hasta
+// que fn devuelva false.
+func (t *Trie[V]) WalkPrefix(prefix string, fn func(key string, value V) bool) {
+	if fn == nil {
+		return
+	}
+	n := t.find(prefix)
+	if n == nil {
+		return
+	}
+	walkTrie(n, []rune(prefix), fn)
+}
+
+// KeysWithPrefix devuelve a lo sumo limit claves que empiezan con prefix, en orden.
+func (t *Trie[V]) KeysWithPrefix(prefix string, limit int) []string {
+	out := make([]string, 0)
+	if limit <= 0 {
+		return out
+	}
+	t.WalkPrefix(prefix, func(key string, _ V) bool {
+		out = append(out, key)
+		return len(out) < limit
+	})
+	return out
+}
+
+func walkTrie[V any](n *trieNode[V], path []rune, fn func(key string, value V) bool) bool {
+	if n.terminal && !fn(string(path), n.value) {
+		return false
+	}
+	for _, e := range n.children {
+		if !walkTrie(e.node, append(path, e.r), fn) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Trie[V]) find(key string) *trieNode[V] {
+	n := t.root
+	for _, r := range key {
+		if n = n.child(r, false); n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// child busca el hijo etiquetado con r por búsqueda binaria y, si create es true, lo
+// inserta en su posición cuando no existe.
+func (n *trieNode[V]) child(r rune, create bool) *trieNode[V] {
+	i := sort.Search(len(n.children), func(i int) bool { return n.children[i].r >= r })
+	if i < len(n.children) && n.children[i].r == r {
+		return n.children[i].node
+	}
+	if !create {
+		return nil
+	}
+	child := &trieNode[V]{}
+	n.children = append(n.children, trieEdge[V]{})
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = trieEdge[V]{r: r, node: child}
+	return child
+}
+
+func (n *trieNode[V]) removeChild(r rune) {
+	i := sort.Search(len(n.children), func(i int) bool { return n.children[i].r >= r })
+	if i < len(n.children) && n.children[i].r == r {
+		n.children = append(n.children[:i], n.children[i+1:]...)
+	}
+}
@@ -0,0 +1,40 @@
+package ds
+
+import "testing"
+
+func TestTriePrefixLookup(t *testing.T) {
+	trie := NewTrie[int]()
+	for i, k := range []string{"garcía", "gabo", "go", "gopher", "rust", "g"} {
+		trie.Put(k, i)
+	}
+	if prev, replaced := trie.Put("go", 10); !replaced || prev != 2 {
+		t.Fatalf("expected replace of go, got replaced=%v prev=%d", replaced, prev)
+	}
+	if trie.Size() != 6 {
+		t.Fatalf("expected size 6, got %d", trie.Size())
+	}
+
+	got := trie.KeysWithPrefix("g", 10)
+	if !equalStrings(got, []string{"g", "gabo", "garcía", "go", "gopher"}) {
+		t.Fatalf("unexpected keys: %v", got)
+	}
+	if got := trie.KeysWithPrefix("ga", 1); !equalStrings(got, []string{"gabo"}) {
+		t.Fatalf("limit not applied: %v", got)
+	}
+	if got := trie.KeysWithPrefix("x", 10); len(got) != 0 {
+		t.Fatalf("expected no keys, got %v", got)
+	}
+
+	if _, ok := trie.Delete("gop"); ok {
+		t.Fatalf("deleting a bare prefix should fail")
+	}
+	if v, ok := trie.Delete("gopher"); !ok || v != 3 {
+		t.Fatalf("delete gopher failed: %d %v", v, ok)
+	}
+	if v, ok := trie.Get("go"); !ok || v != 10 {
+		t.Fatalf("go should survive deleting gopher: %d %v", v, ok)
+	}
+	if trie.find("gop") != nil {
+		t.Fatalf("empty branch should be pruned")
+	}
+}
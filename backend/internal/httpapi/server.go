@@ -2,12 +2,18 @@ package httpapi
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"library/internal/models"
 	"library/internal/services"
+	"library/internal/storage"
 )
 
 type server struct {
@@ -16,7 +22,22 @@ type server struct {
 }
 
 func NewServer() http.Handler {
-	s := &server{svc: services.NewLibraryService(), mux: http.NewServeMux()}
+	kind, err := services.ParseIndexKind(os.Getenv("LIBRARY_INDEX"))
+	if err != nil {
+		log.Fatalf("LIBRARY_INDEX: %v", err)
+	}
+	svc := services.NewLibraryServiceWithIndex(kind)
+	if dir := os.Getenv("LIBRARY_DATA_DIR"); dir != "" {
+		store, err := storage.Open(filepath.Join(dir, "library.db"), storage.Options{})
+		if err != nil {
+			log.Fatalf("open data store: %v", err)
+		}
+		if err := svc.AttachStore(store); err != nil {
+			log.Fatalf("load data store: %v", err)
+		}
+		log.Printf("Persisting library state in %s", dir)
+	}
+	s := &server{svc: svc, mux: http.NewServeMux()}
 	s.routes()
 	return cors(s.mux)
 }
@@ -30,8 +51,22 @@ func (s *server) routes() {
 	s.mux.HandleFunc("/api/users", s.handleUsers)
 	s.mux.HandleFunc("/api/books", s.handleBooks)
 	s.mux.HandleFunc("/api/books/search", s.handleBookSearch)
+	s.mux.HandleFunc("/api/books/autocomplete", s.handleAutocomplete)
+	s.mux.HandleFunc("/api/books/import", s.handleImport)
+	s.mux.HandleFunc("/api/books/copies", s.handleCopies)
+	s.mux.HandleFunc("/api/loans", s.handleLoans)
+	s.mux.HandleFunc("/api/loans/overdue", s.handleOverdueLoans)
 	s.mux.HandleFunc("/api/loans/borrow", s.handleBorrow)
 	s.mux.HandleFunc("/api/loans/return", s.handleReturn)
+	s.mux.HandleFunc("/api/loans/renew", s.handleRenew)
+	s.mux.HandleFunc("/api/holds", s.handleHolds)
+	s.mux.HandleFunc("/api/fines", s.handleFines)
+	s.mux.HandleFunc("/api/fines/pay", s.handlePayFine)
+	s.mux.HandleFunc("/api/fines/waive", s.handleWaiveFine)
+	s.mux.HandleFunc("/api/history", s.handleHistory)
+	s.mux.HandleFunc("/api/admin/stats", s.handleIndexStats)
+	s.mux.HandleFunc("/api/admin/snapshot", s.handleSnapshot)
+	s.mux.HandleFunc("/api/admin/restore", s.handleRestore)
 }
 
 func (s *server) handleUsers(w http.ResponseWriter, r *http.Request) {
@@ -45,7 +80,14 @@ func (s *server) handleUsers(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "missing fields", 400)
 			return
 		}
-		s.svc.AddUser(u)
+		if strings.ContainsRune(u.ID, 0) || len(u.ID) > services.MaxIDLength {
+			http.Error(w, "invalid user id", 400)
+			return
+		}
+		if err := s.svc.AddUser(u); err != nil {
+			http.Error(w, err.Error(), addStatus(err))
+			return
+		}
 		respond(w, 201, u)
 		return
 	}
@@ -63,6 +105,16 @@ func (s *server) handleUsers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method == http.MethodGet {
+		after, limit, paged, err := pageParams(r)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		if paged {
+			items, next := s.svc.ListUsersPage(after, limit)
+			respond(w, 200, pageResponse[models.User]{Items: items, Next: next})
+			return
+		}
 		respond(w, 200, s.svc.ListUsers())
 		return
 	}
@@ -80,8 +132,20 @@ func (s *server) handleBooks(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "missing fields", 400)
 			return
 		}
-		s.svc.AddBook(b)
-		respond(w, 201, b)
+		if len(b.ID) > services.MaxIDLength {
+			http.Error(w, "id too long", 400)
+			return
+		}
+		if b.Copies < 0 || b.Copies > services.MaxCopies {
+			http.Error(w, "invalid copies", 400)
+			return
+		}
+		stored, err := s.svc.AddBook(b)
+		if err != nil {
+			http.Error(w, err.Error(), addStatus(err))
+			return
+		}
+		respond(w, 201, stored)
 		return
 	}
 	if r.Method == http.MethodDelete {
@@ -98,6 +162,16 @@ func (s *server) handleBooks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method == http.MethodGet {
+		after, limit, paged, err := pageParams(r)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		if paged {
+			items, next := s.svc.ListBooksPage(after, limit)
+			respond(w, 200, pageResponse[models.Book]{Items: items, Next: next})
+			return
+		}
 		respond(w, 200, s.svc.ListBooks())
 		return
 	}
@@ -105,8 +179,129 @@ func (s *server) handleBooks(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *server) handleBookSearch(w http.ResponseWriter, r *http.Request) {
-	q := r.URL.Query().Get("q")
-	respond(w, 200, s.svc.SearchBooks(q))
+	query := r.URL.Query()
+	filter := services.BookFilter{Author: query.Get("author")}
+	if raw := query.Get("available"); raw != "" {
+		available, err := strconv.ParseBool(raw)
+		if err != nil {
+			http.Error(w, "invalid available", 400)
+			return
+		}
+		filter.AvailableOnly = available
+	}
+	respond(w, 200, s.svc.SearchBooksFiltered(query.Get("q"), filter))
+}
+
+func (s *server) handleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	var books []models.Book
+	if err := json.NewDecoder(r.Body).Decode(&books); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	result, err := s.svc.ImportBooks(books)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	respond(w, 200, result)
+}
+
+// defaultCompletions is how many suggestions autocomplete returns without a limit.
+const defaultCompletions = 10
+
+func (s *server) handleCopies(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		var req models.CopyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		if req.BookID == "" || req.Barcode == "" {
+			http.Error(w, "missing fields", 400)
+			return
+		}
+		c, err := s.svc.AddCopy(req.BookID, req.Barcode)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		respond(w, 201, c)
+		return
+	}
+	if r.Method == http.MethodDelete {
+		barcode := r.URL.Query().Get("barcode")
+		if barcode == "" {
+			http.Error(w, "missing barcode", 400)
+			return
+		}
+		if err := s.svc.RemoveCopy(barcode); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		respond(w, 200, map[string]string{"status": "deleted"})
+		return
+	}
+	if r.Method == http.MethodGet {
+		bookID := r.URL.Query().Get("bookId")
+		if bookID == "" {
+			http.Error(w, "missing bookId", 400)
+			return
+		}
+		respond(w, 200, s.svc.BookCopies(bookID))
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (s *server) handleAutocomplete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	limit := defaultCompletions
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", 400)
+			return
+		}
+		limit = min(n, maxPageSize)
+	}
+	respond(w, 200, s.svc.Autocomplete(r.URL.Query().Get("prefix"), limit))
+}
+
+// handleLoans lists the active loans, or with ?at= every loan running at that instant
+// (RFC 3339 timestamp or YYYY-MM-DD date, read as UTC midnight).
+func (s *server) handleLoans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	raw := r.URL.Query().Get("at")
+	if raw == "" {
+		respond(w, 200, s.svc.ListLoans())
+		return
+	}
+	at, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		if at, err = time.Parse(time.DateOnly, raw); err != nil {
+			http.Error(w, "invalid at", 400)
+			return
+		}
+	}
+	respond(w, 200, s.svc.LoansActiveAt(at))
+}
+
+func (s *server) handleOverdueLoans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	respond(w, 200, s.svc.OverdueLoans())
 }
 
 func (s *server) handleBorrow(w http.ResponseWriter, r *http.Request) {
@@ -124,7 +319,11 @@ func (s *server) handleBorrow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := s.svc.Borrow(req); err != nil {
-		http.Error(w, err.Error(), 400)
+		code := 400
+		if errors.Is(err, services.ErrUnpaidFines) {
+			code = 402
+		}
+		http.Error(w, err.Error(), code)
 		return
 	}
 	respond(w, 200, map[string]string{"status": "borrowed"})
@@ -151,6 +350,227 @@ func (s *server) handleReturn(w http.ResponseWriter, r *http.Request) {
 	respond(w, 200, map[string]string{"status": "returned"})
 }
 
+func (s *server) handleRenew(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	var req models.LoanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if req.UserID == "" || req.BookID == "" {
+		http.Error(w, "missing fields", 400)
+		return
+	}
+	if err := s.svc.Renew(req.UserID, req.BookID); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	respond(w, 200, map[string]string{"status": "renewed"})
+}
+
+// handleHolds places (POST), cancels (DELETE ?userId&bookId) and lists holds: GET with
+// ?userId shows a patron's holds and positions, with ?bookId the queue of a book.
+func (s *server) handleHolds(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if r.Method == http.MethodPost {
+		var req models.LoanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		if req.UserID == "" || req.BookID == "" {
+			http.Error(w, "missing fields", 400)
+			return
+		}
+		hold, err := s.svc.PlaceHold(req.UserID, req.BookID)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		respond(w, 201, hold)
+		return
+	}
+	if r.Method == http.MethodDelete {
+		userID, bookID := query.Get("userId"), query.Get("bookId")
+		if userID == "" || bookID == "" {
+			http.Error(w, "missing userId or bookId", 400)
+			return
+		}
+		if err := s.svc.CancelHold(userID, bookID); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		respond(w, 200, map[string]string{"status": "cancelled"})
+		return
+	}
+	if r.Method == http.MethodGet {
+		if userID := query.Get("userId"); userID != "" {
+			respond(w, 200, s.svc.UserHolds(userID))
+			return
+		}
+		if bookID := query.Get("bookId"); bookID != "" {
+			respond(w, 200, s.svc.BookHolds(bookID))
+			return
+		}
+		http.Error(w, "missing userId or bookId", 400)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (s *server) handleFines(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	userID := r.URL.Query().Get("userId")
+	if userID == "" {
+		http.Error(w, "missing userId", 400)
+		return
+	}
+	account, err := s.svc.FineAccount(userID)
+	if err != nil {
+		http.Error(w, err.Error(), 404)
+		return
+	}
+	respond(w, 200, account)
+}
+
+func (s *server) handlePayFine(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	var req models.FineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if req.UserID == "" {
+		http.Error(w, "missing fields", 400)
+		return
+	}
+	if err := s.svc.PayFine(req.UserID, req.Amount); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	respond(w, 200, map[string]string{"status": "paid"})
+}
+
+func (s *server) handleWaiveFine(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	var req models.FineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if req.UserID == "" || req.Reason == "" {
+		http.Error(w, "missing fields", 400)
+		return
+	}
+	if err := s.svc.WaiveFine(req.UserID, req.Amount, req.Reason); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	respond(w, 200, map[string]string{"status": "waived"})
+}
+
+// defaultHistory is how many operations /api/history returns without a limit.
+const defaultHistory = 50
+
+func (s *server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	limit := defaultHistory
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", 400)
+			return
+		}
+		limit = n
+	}
+	respond(w, 200, s.svc.History(limit))
+}
+
+func (s *server) handleIndexStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	respond(w, 200, s.svc.IndexStats())
+}
+
+// handleSnapshot sends the binary snapshot written by LibraryService.Snapshot.
+func (s *server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if err := s.svc.Snapshot(w); err != nil {
+		log.Println("snapshot error:", err)
+	}
+}
+
+func (s *server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.svc.Restore(r.Body); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	respond(w, 200, map[string]string{"status": "restored"})
+}
+
+// maxPageSize caps the limit query parameter of paged list endpoints.
+const maxPageSize = 500
+
+// pageResponse is returned by list endpoints when the client asks for a page. Next is
+// the value to send as after to fetch the following page; it is omitted on the last one.
+type pageResponse[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next,omitempty"`
+}
+
+// pageParams reads the after and limit query parameters. Requests without either one
+// keep the unpaged response so existing clients are unaffected.
+func pageParams(r *http.Request) (string, int, bool, error) {
+	q := r.URL.Query()
+	after, rawLimit := q.Get("after"), q.Get("limit")
+	if after == "" && rawLimit == "" {
+		return "", 0, false, nil
+	}
+	limit := maxPageSize
+	if rawLimit != "" {
+		n, err := strconv.Atoi(rawLimit)
+		if err != nil || n <= 0 {
+			return "", 0, false, errors.New("invalid limit")
+		}
+		limit = min(n, maxPageSize)
+	}
+	return after, limit, true, nil
+}
+
+// addStatus is the status for a failed add: a record over the store's size limits is a
+// bad request, anything else a server error.
+func addStatus(err error) int {
+	if errors.Is(err, storage.ErrEntryTooLarge) {
+		return 413
+	}
+	return 500
+}
+
 func respond(w http.ResponseWriter, code int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
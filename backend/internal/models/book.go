@@ -1,9 +1,13 @@
 package models
 
+// Book is a title in the catalog. What circulates are its copies (see Copy): Copies and
+// AvailableCopies count them, and Available is true while at least one is on the shelf.
 type Book struct {
-	ID     string `json:"id"`
-	Title  string `json:"title"`
-	Author string `json:"author"`
-	ISBN   string `json:"isbn"`
-	Available bool `json:"available"`
+	ID              string `json:"id"`
+	Title           string `json:"title"`
+	Author          string `json:"author"`
+	ISBN            string `json:"isbn"`
+	Available       bool   `json:"available"`
+	Copies          int    `json:"copies"`
+	AvailableCopies int    `json:"availableCopies"`
 }
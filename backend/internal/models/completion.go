@@ -0,0 +1,8 @@
+package models
+
+// Completion is an autocomplete suggestion: a title or author with a word that starts
+// with the typed prefix, with the IDs of the books it belongs to.
+type Completion struct {
+	Text    string   `json:"text"`
+	BookIDs []string `json:"bookIds"`
+}
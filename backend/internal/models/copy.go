@@ -0,0 +1,21 @@
+package models
+
+// Copy statuses.
+const (
+	CopyAvailable = "available"
+	CopyLoaned    = "loaned"
+	// CopyOnHold is a returned copy set aside for the patron whose hold is ready.
+	CopyOnHold = "on_hold"
+)
+
+// Copy is one physical item of a book, identified by its barcode.
+type Copy struct {
+	Barcode string `json:"barcode"`
+	BookID  string `json:"bookId"`
+	Status  string `json:"status"`
+}
+
+type CopyRequest struct {
+	BookID  string `json:"bookId"`
+	Barcode string `json:"barcode"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Kinds of fine ledger entries. Fines raise the balance a user owes; payments and
+// waivers lower it.
+const (
+	EntryFine    = "fine"
+	EntryPayment = "payment"
+	EntryWaiver  = "waiver"
+)
+
+// LedgerEntry is one movement in a user's fines ledger. Amount is always positive; Kind
+// says in which direction it moves the balance.
+type LedgerEntry struct {
+	ID     string    `json:"id"`
+	UserID string    `json:"userId"`
+	Kind   string    `json:"kind"`
+	Amount Money     `json:"amount"`
+	BookID string    `json:"bookId,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// FineAccount is a user's outstanding balance and the ledger it comes from, oldest
+// entry first.
+type FineAccount struct {
+	UserID  string        `json:"userId"`
+	Balance Money         `json:"balance"`
+	Entries []LedgerEntry `json:"entries"`
+}
+
+// FineRequest is the body of the payment and waiver endpoints.
+type FineRequest struct {
+	UserID string `json:"userId"`
+	Amount Money  `json:"amount"`
+	Reason string `json:"reason"`
+}
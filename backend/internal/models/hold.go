@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Hold is a patron's place in the queue for a book. When a copy comes back it is set
+// aside for the first hold in line still waiting, which becomes ready: ReadyAt and
+// Barcode are set and only that patron can borrow the copy. Position is 1 for the front
+// of the queue.
+type Hold struct {
+	ID       string     `json:"id"`
+	UserID   string     `json:"userId"`
+	BookID   string     `json:"bookId"`
+	PlacedAt time.Time  `json:"placedAt"`
+	ReadyAt  *time.Time `json:"readyAt,omitempty"`
+	Barcode  string     `json:"barcode,omitempty"`
+	Position int        `json:"position,omitempty"`
+}
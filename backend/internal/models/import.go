@@ -0,0 +1,13 @@
+package models
+
+// ImportResult reports which books of a bulk import were added and which were rejected.
+type ImportResult struct {
+	Imported []string         `json:"imported"`
+	Rejected []ImportRejected `json:"rejected"`
+}
+
+type ImportRejected struct {
+	ID     string `json:"id"`
+	ISBN   string `json:"isbn"`
+	Reason string `json:"reason"`
+}
@@ -1,6 +1,20 @@
 package models
 
+import "time"
+
 type LoanRequest struct {
 	UserID string `json:"userId"`
 	BookID string `json:"bookId"`
 }
+
+// Loan is a copy of a book lent to a user. ReturnedAt stays nil while the loan is active, and
+// Renewals counts how many times the due date was pushed forward.
+type Loan struct {
+	UserID     string     `json:"userId"`
+	BookID     string     `json:"bookId"`
+	Barcode    string     `json:"barcode"`
+	BorrowedAt time.Time  `json:"borrowedAt"`
+	DueAt      time.Time  `json:"dueAt"`
+	ReturnedAt *time.Time `json:"returnedAt,omitempty"`
+	Renewals   int        `json:"renewals"`
+}
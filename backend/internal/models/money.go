@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount in cents. In JSON it is written as a decimal string such as
+// "12.50" and read from either a string or a number, always without going through
+// float64.
+type Money int64
+
+var errInvalidMoney = errors.New("invalid amount: expected a number with at most two decimals")
+
+// ParseMoney reads amounts like "12", "12.5" or "-0.75".
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if whole == "" || hasFrac && (frac == "" || len(frac) > 2) {
+		return 0, errInvalidMoney
+	}
+	for _, part := range []string{whole, frac} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return 0, errInvalidMoney
+			}
+		}
+	}
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > (1<<63-1)/100-1 {
+		return 0, errInvalidMoney
+	}
+	cents := int64(0)
+	if hasFrac {
+		cents, _ = strconv.ParseInt((frac + "0")[:2], 10, 64)
+	}
+	amount := Money(units*100 + cents)
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	raw := string(data)
+	if strings.HasPrefix(raw, `"`) {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseMoney(raw)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
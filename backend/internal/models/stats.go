@@ -0,0 +1,12 @@
+package models
+
+// IndexStats describes the shape of one service index. AverageDepth and Leaves are only
+// reported for indexes backed by a binary search tree.
+type IndexStats struct {
+	Name         string  `json:"name"`
+	Kind         string  `json:"kind"`
+	Size         int     `json:"size"`
+	Height       int     `json:"height,omitempty"`
+	AverageDepth float64 `json:"averageDepth,omitempty"`
+	Leaves       int     `json:"leaves,omitempty"`
+}
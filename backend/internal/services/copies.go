@@ -0,0 +1,261 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"library/internal/ds"
+	"library/internal/models"
+	"library/internal/storage"
+)
+
+// MaxCopies is how many copies a book may be created with at once; more are added one
+// by one with AddCopy.
+const MaxCopies = 100
+
+// generatedBarcodePrefix starts the barcodes of the copies created together with a
+// book. AddCopy refuses it, so staff barcodes never collide with generated ones.
+const generatedBarcodePrefix = "auto-"
+
+// AddCopy registers another copy of the book. If patrons are waiting for the book the
+// new copy is set aside for the first of them; otherwise it goes on the shelf.
+func (s *LibraryService) AddCopy(bookID, barcode string) (models.Copy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	barcode = strings.TrimSpace(barcode)
+	if barcode == "" {
+		return models.Copy{}, errors.New("barcode required")
+	}
+	if len(barcode) > MaxIDLength {
+		return models.Copy{}, fmt.Errorf("barcode longer than %d bytes", MaxIDLength)
+	}
+	if strings.HasPrefix(barcode, generatedBarcodePrefix) {
+		return models.Copy{}, errors.New("barcode prefix " + generatedBarcodePrefix + " is reserved")
+	}
+	book, ok := s.books.Get(bookID)
+	if !ok {
+		return models.Copy{}, errors.New("book not found")
+	}
+	if s.copies.Contains(barcode) {
+		return models.Copy{}, errors.New("barcode already in use")
+	}
+	var batch storage.Batch
+	release, err := s.releaseCopy(&batch, book, models.Copy{Barcode: barcode, BookID: bookID})
+	if err != nil {
+		return models.Copy{}, err
+	}
+	if err := s.persist(&batch); err != nil {
+		return models.Copy{}, err
+	}
+	release()
+	s.history.Push("add_copy:" + bookID + ":" + barcode)
+	c, _ := s.copies.Get(barcode)
+	return c, nil
+}
+
+// RemoveCopy withdraws a copy that is on the shelf. A book keeps at least one copy;
+// RemoveBook withdraws the title altogether.
+func (s *LibraryService) RemoveCopy(barcode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.copies.Get(barcode)
+	if !ok {
+		return errors.New("copy not found")
+	}
+	if c.Status != models.CopyAvailable {
+		return errors.New("copy not on the shelf")
+	}
+	book, ok := s.books.Get(c.BookID)
+	if !ok {
+		return errors.New("book not found")
+	}
+	if book.Copies <= 1 {
+		return errors.New("last copy of the book")
+	}
+	book = recount(book, c.Status, "")
+	var batch storage.Batch
+	deleteRecord(&batch, copyPrefix, barcode)
+	if err := putRecord(&batch, bookPrefix, book.ID, book); err != nil {
+		return err
+	}
+	if err := s.persist(&batch); err != nil {
+		return err
+	}
+	s.copies.Delete(barcode)
+	s.bookCopies[c.BookID].Remove(barcode)
+	s.books.Put(book.ID, book)
+	s.invalidateSearches()
+	s.history.Push("remove_copy:" + c.BookID + ":" + barcode)
+	return nil
+}
+
+// BookCopies lists the copies of a book in barcode order.
+func (s *LibraryService) BookCopies(bookID string) []models.Copy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]models.Copy, 0)
+	for _, barcode := range s.barcodes(bookID) {
+		if c, ok := s.copies.Get(barcode); ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// stockBook adds to batch a book about to be added. A new book gets b.Copies copies (at
+// least one, at most MaxCopies) on the shelf, which are returned; a book that already
+// exists keeps its copies and their counts. On error batch is left as it was, so
+// ImportBooks can go on with the next book.
+func (s *LibraryService) stockBook(batch *storage.Batch, b models.Book) (models.Book, []models.Copy, error) {
+	if len(b.ID) > MaxIDLength {
+		return models.Book{}, nil, fmt.Errorf("book id longer than %d bytes", MaxIDLength)
+	}
+	if b.Copies < 0 || b.Copies > MaxCopies {
+		return models.Book{}, nil, fmt.Errorf("copies must be between 0 and %d", MaxCopies)
+	}
+	var copies []models.Copy
+	if current, ok := s.books.Get(b.ID); ok {
+		b.Available, b.Copies, b.AvailableCopies = current.Available, current.Copies, current.AvailableCopies
+	} else {
+		n := max(b.Copies, 1)
+		copies = make([]models.Copy, 0, n)
+		for i := 1; i <= n; i++ {
+			c := models.Copy{Barcode: copyBarcode(b.ID, i), BookID: b.ID, Status: models.CopyAvailable}
+			if s.copies.Contains(c.Barcode) {
+				return models.Book{}, nil, errors.New("barcode already in use")
+			}
+			copies = append(copies, c)
+		}
+		b.Available, b.Copies, b.AvailableCopies = true, n, n
+	}
+	var records storage.Batch
+	for _, c := range copies {
+		if err := putRecord(&records, copyPrefix, c.Barcode, c); err != nil {
+			return models.Book{}, nil, err
+		}
+	}
+	if err := putRecord(&records, bookPrefix, b.ID, b); err != nil {
+		return models.Book{}, nil, err
+	}
+	batch.Append(&records)
+	return b, copies, nil
+}
+
+// copyBarcode names the n-th copy created together with a book. Book IDs are unique and
+// n has no hyphen, so different books never get the same barcode.
+func copyBarcode(bookID string, n int) string {
+	return fmt.Sprintf("%s%s-%d", generatedBarcodePrefix, bookID, n)
+}
+
+// releaseCopy adds to batch what happens when a copy comes free, or is added: it is set
+// aside for the first hold of its book still waiting, or goes on the shelf when nobody
+// is waiting. book is updated with the new counts. The returned function applies the
+// change in memory once the batch is written.
+func (s *LibraryService) releaseCopy(batch *storage.Batch, book models.Book, c models.Copy) (func(), error) {
+	next := s.waitingHold(c.BookID)
+	var ready models.Hold
+	status := models.CopyAvailable
+	if next != nil {
+		ready = next.Value
+		now := s.clock.Now()
+		ready.ReadyAt = &now
+		ready.Barcode = c.Barcode
+		if err := putRecord(batch, holdPrefix, holdKey(ready), ready); err != nil {
+			return nil, err
+		}
+		status = models.CopyOnHold
+	}
+	book = recount(book, c.Status, status)
+	c.Status = status
+	if err := putRecord(batch, copyPrefix, c.Barcode, c); err != nil {
+		return nil, err
+	}
+	if err := putRecord(batch, bookPrefix, book.ID, book); err != nil {
+		return nil, err
+	}
+	return func() {
+		if next != nil {
+			next.Value = ready
+		}
+		s.putCopy(c)
+		s.books.Put(book.ID, book)
+		s.invalidateSearches()
+	}, nil
+}
+
+// recount adjusts the copy counts of a book for one copy going from status from to
+// status to. An empty status stands for a copy that does not exist.
+func recount(book models.Book, from, to string) models.Book {
+	if from == "" {
+		book.Copies++
+	}
+	if to == "" {
+		book.Copies--
+	}
+	if from == models.CopyAvailable {
+		book.AvailableCopies--
+	}
+	if to == models.CopyAvailable {
+		book.AvailableCopies++
+	}
+	book.Available = book.AvailableCopies > 0
+	return book
+}
+
+// shelfCopy returns the first copy of the book, by barcode, that is on the shelf.
+func (s *LibraryService) shelfCopy(bookID string) (models.Copy, bool) {
+	for _, barcode := range s.barcodes(bookID) {
+		if c, ok := s.copies.Get(barcode); ok && c.Status == models.CopyAvailable {
+			return c, true
+		}
+	}
+	return models.Copy{}, false
+}
+
+// findLoan returns the user's active loan of a copy of the book. Patrons borrow at most
+// one copy of each book, so there is at most one.
+func (s *LibraryService) findLoan(userID, bookID string) (models.Loan, error) {
+	lent := false
+	for _, barcode := range s.barcodes(bookID) {
+		if loan, ok := s.activeLoans.Get(barcode); ok {
+			if loan.UserID == userID {
+				return loan, nil
+			}
+			lent = true
+		}
+	}
+	if lent {
+		return models.Loan{}, errors.New("loan belongs to a different user")
+	}
+	return models.Loan{}, errors.New("loan not found")
+}
+
+// barcodes returns the barcodes of the book's copies, sorted.
+func (s *LibraryService) barcodes(bookID string) []string {
+	if set, ok := s.bookCopies[bookID]; ok {
+		return set.Items()
+	}
+	return nil
+}
+
+// putCopy stores the copy in the copies index and in its book's set of barcodes.
+func (s *LibraryService) putCopy(c models.Copy) {
+	s.copies.Put(c.Barcode, c)
+	s.shelveCopy(c)
+}
+
+func (s *LibraryService) shelveCopy(c models.Copy) {
+	set, ok := s.bookCopies[c.BookID]
+	if !ok {
+		set = ds.NewSet[string](strings.Compare)
+		s.bookCopies[c.BookID] = set
+	}
+	set.Add(c.Barcode)
+}
+
+// restoreBookCopies rebuilds the per-book barcode sets from the copies index.
+func (s *LibraryService) restoreBookCopies() {
+	s.bookCopies = make(map[string]*ds.Set[string])
+	s.copies.TraverseInOrder(func(_ string, c models.Copy) { s.shelveCopy(c) })
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"testing"
+
+	"library/internal/models"
+)
+
+func TestBorrowTakesAnyAvailableCopy(t *testing.T) {
+	s, _ := newClockedService(t)
+	s.AddUser(models.User{ID: "u3", Name: "Eva"})
+	s.AddBook(models.Book{ID: "b3", Title: "Go Patterns", Author: "Gopher", Copies: 3})
+
+	if _, err := s.AddBook(models.Book{ID: "b4", Title: "Huge", Author: "Anon", Copies: MaxCopies + 1}); err == nil {
+		t.Fatalf("more than MaxCopies copies should be refused")
+	}
+	if got := s.BookCopies("b3"); len(got) != 3 || got[0].Barcode != "auto-b3-1" || got[2].Status != models.CopyAvailable {
+		t.Fatalf("unexpected copies: %+v", got)
+	}
+	if err := s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b3"}); err != nil {
+		t.Fatalf("borrow: %v", err)
+	}
+	if err := s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b3"}); err == nil {
+		t.Fatalf("a user should not borrow two copies of a book")
+	}
+	if err := s.Borrow(models.LoanRequest{UserID: "u2", BookID: "b3"}); err != nil {
+		t.Fatalf("borrow second copy: %v", err)
+	}
+	books := s.SearchBooks("patterns")
+	if len(books) != 1 || books[0].Copies != 3 || books[0].AvailableCopies != 1 || !books[0].Available {
+		t.Fatalf("search should show 1 of 3 available: %+v", books)
+	}
+	if loans := s.ListLoans(); len(loans) != 2 || loans[0].Barcode != "auto-b3-1" || loans[1].Barcode != "auto-b3-2" {
+		t.Fatalf("each loan should hold its own copy: %+v", loans)
+	}
+
+	if err := s.Borrow(models.LoanRequest{UserID: "u3", BookID: "b3"}); err != nil {
+		t.Fatalf("borrow last copy: %v", err)
+	}
+	if got := bookIDs(s.SearchBooksFiltered("", BookFilter{AvailableOnly: true})); !equalIDs(got, []string{"b1", "b2"}) {
+		t.Fatalf("a book with every copy out is not available: %v", got)
+	}
+	if err := s.RemoveBook("b3"); err == nil {
+		t.Fatalf("a book with loaned copies should not be removed")
+	}
+
+	if err := s.Return(models.LoanRequest{UserID: "u2", BookID: "b3"}); err != nil {
+		t.Fatalf("return: %v", err)
+	}
+	if got := s.BookCopies("b3"); got[1].Status != models.CopyAvailable || got[0].Status != models.CopyLoaned {
+		t.Fatalf("only the returned copy should be back: %+v", got)
+	}
+	if err := s.Return(models.LoanRequest{UserID: "u2", BookID: "b3"}); err == nil {
+		t.Fatalf("second return should fail")
+	}
+}
+
+func TestAddAndRemoveCopies(t *testing.T) {
+	s, _ := newClockedService(t)
+	s.AddUser(models.User{ID: "u3", Name: "Eva"})
+	if _, err := s.AddCopy("b1", "auto-b2-1"); err == nil {
+		t.Fatalf("generated barcodes are reserved")
+	}
+	if _, err := s.AddCopy("b1", "b5-1"); err != nil {
+		t.Fatalf("add copy: %v", err)
+	}
+	if _, err := s.AddCopy("b2", "b5-1"); err == nil {
+		t.Fatalf("barcodes are unique across books")
+	}
+	if b, err := s.AddBook(models.Book{ID: "b5", Title: "C", Author: "K&R"}); err != nil || b.Copies != 1 || b.AvailableCopies != 1 || !b.Available {
+		t.Fatalf("staff barcodes should not clash with generated ones: %+v, %v", b, err)
+	}
+	s.RemoveCopy("b5-1")
+	if err := s.RemoveCopy("auto-b1-1"); err == nil {
+		t.Fatalf("the last copy of a book should not be removed")
+	}
+
+	s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"})
+	s.PlaceHold("u2", "b1")
+	s.PlaceHold("u3", "b1")
+	c, err := s.AddCopy("b1", "go-extra")
+	if err != nil || c.Status != models.CopyOnHold {
+		t.Fatalf("a new copy should go to the first hold: %+v, %v", c, err)
+	}
+	holds := s.BookHolds("b1")
+	if holds[0].ReadyAt == nil || holds[0].Barcode != "go-extra" || holds[1].ReadyAt != nil {
+		t.Fatalf("unexpected holds: %+v", holds)
+	}
+	if err := s.RemoveCopy("go-extra"); err == nil {
+		t.Fatalf("a copy set aside should not be removed")
+	}
+	if err := s.Renew("u1", "b1"); err == nil {
+		t.Fatalf("renewal should be refused while u3 waits")
+	}
+
+	// The returned copy goes to u3, the hold still waiting.
+	s.Return(models.LoanRequest{UserID: "u1", BookID: "b1"})
+	if got := s.UserHolds("u3"); len(got) != 1 || got[0].Barcode != "auto-b1-1" {
+		t.Fatalf("returned copy should be set aside for u3: %+v", got)
+	}
+	if err := s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"}); err == nil {
+		t.Fatalf("both copies are set aside for others")
+	}
+	if err := s.Borrow(models.LoanRequest{UserID: "u2", BookID: "b1"}); err != nil {
+		t.Fatalf("borrow by holder: %v", err)
+	}
+	if loans := s.ListLoans(); len(loans) != 1 || loans[0].Barcode != "go-extra" {
+		t.Fatalf("holder should get the copy set aside for them: %+v", loans)
+	}
+	if err := s.CancelHold("u3", "b1"); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if b := s.SearchBooks("go")[0]; b.Copies != 2 || b.AvailableCopies != 1 {
+		t.Fatalf("cancelled hold should put its copy on the shelf: %+v", b)
+	}
+	if err := s.RemoveCopy("auto-b1-1"); err != nil {
+		t.Fatalf("remove copy: %v", err)
+	}
+	if b := s.SearchBooks("go")[0]; b.Copies != 1 || b.Available {
+		t.Fatalf("unexpected counts after removal: %+v", b)
+	}
+}
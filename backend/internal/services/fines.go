@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"library/internal/models"
+	"library/internal/storage"
+)
+
+// Fine policy: each day (or part of a day) a book is returned late costs dailyFine, up
+// to maxFine per loan. A user who owes more than borrowLimit cannot borrow.
+const (
+	dailyFine   models.Money = 50
+	maxFine     models.Money = 2000
+	borrowLimit models.Money = 1000
+)
+
+// maxReasonLength caps a waiver reason, in bytes, so every ledger entry fits in one
+// store record whether or not a store is attached.
+const maxReasonLength = 200
+
+// ErrUnpaidFines is returned by Borrow when the user's fines balance is over the limit.
+var ErrUnpaidFines = errors.New("unpaid fines over the borrowing limit")
+
+// FineAccount returns the user's fines balance and ledger.
+func (s *LibraryService) FineAccount(userID string) (models.FineAccount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.users.Get(userID); !ok {
+		return models.FineAccount{}, errors.New("user not found")
+	}
+	account := models.FineAccount{UserID: userID, Entries: make([]models.LedgerEntry, 0)}
+	s.ledgerRange(userID, func(e models.LedgerEntry) {
+		account.Entries = append(account.Entries, e)
+	})
+	account.Balance = s.balance(userID)
+	return account, nil
+}
+
+// PayFine records a full or partial payment. Paying more than is owed is refused.
+func (s *LibraryService) PayFine(userID string, amount models.Money) error {
+	return s.settleFine(userID, models.EntryPayment, amount, "")
+}
+
+// WaiveFine forgives part or all of the balance; staff must give a reason of at most
+// maxReasonLength bytes.
+func (s *LibraryService) WaiveFine(userID string, amount models.Money, reason string) error {
+	if reason == "" {
+		return errors.New("waiver needs a reason")
+	}
+	if len(reason) > maxReasonLength {
+		return fmt.Errorf("reason longer than %d bytes", maxReasonLength)
+	}
+	return s.settleFine(userID, models.EntryWaiver, amount, reason)
+}
+
+func (s *LibraryService) settleFine(userID, kind string, amount models.Money, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users.Get(userID); !ok {
+		return errors.New("user not found")
+	}
+	if amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	if amount > s.balance(userID) {
+		return errors.New("amount exceeds balance")
+	}
+	entry := s.newLedgerEntry(userID, kind, amount)
+	entry.Reason = reason
+	var batch storage.Batch
+	if err := putRecord(&batch, finePrefix, ledgerKey(entry), entry); err != nil {
+		return err
+	}
+	if err := s.persist(&batch); err != nil {
+		return err
+	}
+	s.ledger.Put(ledgerKey(entry), entry)
+	s.history.Push(kind + ":" + userID + ":" + amount.String())
+	return nil
+}
+
+// lateFine is what returning the loan at returnedAt costs, zero when it is on time.
+func lateFine(loan models.Loan, returnedAt time.Time) models.Money {
+	late := returnedAt.Sub(loan.DueAt)
+	if loan.DueAt.IsZero() || late <= 0 {
+		return 0
+	}
+	days := (late + 24*time.Hour - 1) / (24 * time.Hour)
+	return min(models.Money(days)*dailyFine, maxFine)
+}
+
+// newLedgerEntry stamps an entry with the next ID and the current time. Callers hold mu.
+func (s *LibraryService) newLedgerEntry(userID, kind string, amount models.Money) models.LedgerEntry {
+	s.ledgerSeq++
+	return models.LedgerEntry{
+		ID:     fmt.Sprintf("%012d", s.ledgerSeq),
+		UserID: userID,
+		Kind:   kind,
+		Amount: amount,
+		At:     s.clock.Now(),
+	}
+}
+
+// balance adds up the user's ledger. Callers hold mu.
+func (s *LibraryService) balance(userID string) models.Money {
+	var total models.Money
+	s.ledgerRange(userID, func(e models.LedgerEntry) {
+		if e.Kind == models.EntryFine {
+			total += e.Amount
+		} else {
+			total -= e.Amount
+		}
+	})
+	return total
+}
+
+// ledgerRange visits the user's entries in order. Ledger keys are userID, a NUL byte and
+// the entry ID; user IDs cannot contain NUL (see AddUser), so [userID NUL, userID 0x01)
+// holds exactly that user's entries and never those of IDs that extend it.
+func (s *LibraryService) ledgerRange(userID string, fn func(models.LedgerEntry)) {
+	s.ledger.Range(userID+"\x00", userID+"\x01", func(_ string, e models.LedgerEntry) bool {
+		fn(e)
+		return true
+	})
+}
+
+func ledgerKey(e models.LedgerEntry) string {
+	return e.UserID + "\x00" + e.ID
+}
+
+// restoreLedgerSeq continues entry IDs after the highest one in the ledger.
+func (s *LibraryService) restoreLedgerSeq() {
+	s.ledgerSeq = 0
+	s.ledger.TraverseInOrder(func(_ string, e models.LedgerEntry) {
+		if n, err := strconv.ParseUint(e.ID, 10, 64); err == nil {
+			s.ledgerSeq = max(s.ledgerSeq, n)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"library/internal/models"
+)
+
+func TestLateReturnChargesDailyFine(t *testing.T) {
+	s, clock := newClockedService(t)
+	s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"})
+	// Two days and one hour late count as three days.
+	clock.advance(loanPeriod + 49*time.Hour)
+	if err := s.Return(models.LoanRequest{UserID: "u1", BookID: "b1"}); err != nil {
+		t.Fatalf("return: %v", err)
+	}
+	account, err := s.FineAccount("u1")
+	if err != nil {
+		t.Fatalf("account: %v", err)
+	}
+	if account.Balance != 3*dailyFine || len(account.Entries) != 1 || account.Entries[0].BookID != "b1" {
+		t.Fatalf("unexpected account: %+v", account)
+	}
+
+	s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b2"})
+	clock.advance(loanPeriod + 365*24*time.Hour)
+	s.Return(models.LoanRequest{UserID: "u1", BookID: "b2"})
+	if account, _ := s.FineAccount("u1"); account.Balance != 3*dailyFine+maxFine {
+		t.Fatalf("fine per loan should be capped, balance %s", account.Balance)
+	}
+}
+
+func TestPaymentsWaiversAndBorrowBlock(t *testing.T) {
+	s, clock := newClockedService(t)
+	s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"})
+	clock.advance(loanPeriod + 30*24*time.Hour)
+	s.Return(models.LoanRequest{UserID: "u1", BookID: "b1"})
+
+	if err := s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b2"}); !errors.Is(err, ErrUnpaidFines) {
+		t.Fatalf("balance over the limit should block borrowing, got %v", err)
+	}
+	if err := s.PayFine("u1", 400); err != nil {
+		t.Fatalf("partial payment: %v", err)
+	}
+	if err := s.WaiveFine("u1", 100, ""); err == nil {
+		t.Fatalf("waiver without reason should fail")
+	}
+	if err := s.WaiveFine("u1", 100, strings.Repeat("x", maxReasonLength+1)); err == nil {
+		t.Fatalf("overlong reason should fail")
+	}
+	if err := s.WaiveFine("u1", 100, "first offense"); err != nil {
+		t.Fatalf("waiver: %v", err)
+	}
+	if err := s.PayFine("u1", 5000); err == nil {
+		t.Fatalf("overpayment should fail")
+	}
+	if err := s.RemoveUser("u1"); err == nil {
+		t.Fatalf("users with a balance should not be removable")
+	}
+
+	account, _ := s.FineAccount("u1")
+	if account.Balance != 1000 || len(account.Entries) != 3 || account.Entries[2].Reason != "first offense" {
+		t.Fatalf("unexpected account: %+v", account)
+	}
+	// Exactly at the limit is still allowed.
+	if err := s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b2"}); err != nil {
+		t.Fatalf("borrow at the limit: %v", err)
+	}
+	if account, _ := s.FineAccount("u2"); account.Balance != 0 || len(account.Entries) != 0 {
+		t.Fatalf("ledgers should be per user: %+v", account)
+	}
+}
+
+func TestLedgerKeepsOverlappingUserIDsApart(t *testing.T) {
+	s, clock := newClockedService(t)
+	if err := s.AddUser(models.User{ID: "u1\x00x", Name: "Bad"}); err == nil {
+		t.Fatalf("user IDs with NUL should be refused")
+	}
+	s.AddUser(models.User{ID: "u1/x", Name: "Eva"})
+	s.AddUser(models.User{ID: "u10", Name: "Leo"})
+	s.Borrow(models.LoanRequest{UserID: "u1/x", BookID: "b1"})
+	s.Borrow(models.LoanRequest{UserID: "u10", BookID: "b2"})
+	clock.advance(loanPeriod + 30*24*time.Hour)
+	s.Return(models.LoanRequest{UserID: "u1/x", BookID: "b1"})
+	s.Return(models.LoanRequest{UserID: "u10", BookID: "b2"})
+
+	if account, _ := s.FineAccount("u1/x"); account.Balance != 1500 {
+		t.Fatalf("u1/x should owe 15.00: %+v", account)
+	}
+	account, _ := s.FineAccount("u1")
+	if account.Balance != 0 || len(account.Entries) != 0 {
+		t.Fatalf("u1 should not see fines of u1/x or u10: %+v", account)
+	}
+	if err := s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"}); err != nil {
+		t.Fatalf("u1 owes nothing and may borrow: %v", err)
+	}
+}
+
+func TestMoneyIsExact(t *testing.T) {
+	for in, want := range map[string]models.Money{"12": 1200, "0.1": 10, "0.05": 5, "-3.5": -350} {
+		got, err := models.ParseMoney(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseMoney(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "1.234", "1.", "abc", "1e3"} {
+		if _, err := models.ParseMoney(in); err == nil {
+			t.Fatalf("ParseMoney(%q) should fail", in)
+		}
+	}
+
+	var req models.FineRequest
+	if err := json.Unmarshal([]byte(`{"userId":"u1","amount":0.3}`), &req); err != nil || req.Amount != 30 {
+		t.Fatalf("number amount: %d, %v", req.Amount, err)
+	}
+	raw, _ := json.Marshal(models.LedgerEntry{Amount: 1999})
+	var decoded map[string]any
+	json.Unmarshal(raw, &decoded)
+	if decoded["amount"] != "19.99" {
+		t.Fatalf("amount should be encoded as a decimal string: %s", raw)
+	}
+}
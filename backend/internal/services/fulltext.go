@@ -0,0 +1,226 @@
+package services
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"library/internal/ds"
+)
+
+// Okapi BM25 parameters: k1 limits how much repeating a term raises a score and b how
+// much longer titles and author names are penalized.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// textIndex is an inverted index over book text. Each term maps to its posting list,
+// the ordered set of IDs of the books that contain it; the terms are kept in a trie so
+// a partial word can be expanded to every term it starts. docs keeps the term
+// frequencies of each book for scoring and for removing it later.
+type textIndex struct {
+	postings    *ds.Trie[*ds.Set[string]]
+	docs        map[string]map[string]int
+	totalLength int
+}
+
+func newTextIndex() *textIndex {
+	return &textIndex{
+		postings: ds.NewTrie[*ds.Set[string]](),
+		docs:     make(map[string]map[string]int),
+	}
+}
+
+func (ix *textIndex) add(id string, terms []string) {
+	ix.remove(id)
+	freqs := make(map[string]int)
+	for _, term := range terms {
+		posting, ok := ix.postings.Get(term)
+		if !ok {
+			posting = ds.NewSet[string](strings.Compare)
+			ix.postings.Put(term, posting)
+		}
+		posting.Add(id)
+		freqs[term]++
+	}
+	ix.docs[id] = freqs
+	ix.totalLength += len(terms)
+}
+
+func (ix *textIndex) remove(id string) {
+	freqs, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	for term, n := range freqs {
+		posting, _ := ix.postings.Get(term)
+		posting.Remove(id)
+		if posting.Size() == 0 {
+			ix.postings.Delete(term)
+		}
+		ix.totalLength -= n
+	}
+	delete(ix.docs, id)
+}
+
+// matches returns the books that contain every term of at least one clause. The last
+// term of the query may be a word still being typed, so it matches every term it is a
+// prefix of: "garcia mar" finds "García Márquez".
+func (ix *textIndex) matches(clauses [][]string) *ds.Set[string] {
+	out := ds.NewSet[string](strings.Compare)
+	for i, clause := range clauses {
+		if i < len(clauses)-1 {
+			out = out.Union(ix.matchAll(clause))
+			continue
+		}
+		last := len(clause) - 1
+		found := ix.matchPrefix(clause[last])
+		if last > 0 {
+			found = found.Intersection(ix.matchAll(clause[:last]))
+		}
+		out = out.Union(found)
+	}
+	return out
+}
+
+// matchPrefix unites the posting lists of every term that starts with prefix. Like
+// matchAll, the result may be a posting list itself. The IDs of all the lists are
+// gathered, sorted and deduplicated once, so a short prefix that starts thousands of
+// terms costs O(n log n) in the IDs found rather than a Union per term.
+func (ix *textIndex) matchPrefix(prefix string) *ds.Set[string] {
+	var lists []*ds.Set[string]
+	total := 0
+	ix.postings.WalkPrefix(prefix, func(_ string, posting *ds.Set[string]) bool {
+		lists = append(lists, posting)
+		total += posting.Size()
+		return true
+	})
+	switch len(lists) {
+	case 0:
+		return ds.NewSet[string](strings.Compare)
+	case 1:
+		return lists[0]
+	}
+	ids := make([]string, 0, total)
+	for _, posting := range lists {
+		posting.Ascend(func(id string) bool {
+			ids = append(ids, id)
+			return true
+		})
+	}
+	slices.Sort(ids)
+	// Sorted and without duplicates, so building the set cannot fail.
+	out, _ := ds.NewSetFromSorted(strings.Compare, slices.Compact(ids))
+	return out
+}
+
+// matchAll intersects the posting lists of terms, starting from the shortest one. The
+// result may be a posting list itself, so callers must not modify it.
+func (ix *textIndex) matchAll(terms []string) *ds.Set[string] {
+	lists := make([]*ds.Set[string], 0, len(terms))
+	for _, term := range terms {
+		posting, ok := ix.postings.Get(term)
+		if !ok {
+			return ds.NewSet[string](strings.Compare)
+		}
+		lists = append(lists, posting)
+	}
+	if len(lists) == 0 {
+		return ds.NewSet[string](strings.Compare)
+	}
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Size() < lists[j].Size() })
+	out := lists[0]
+	for _, other := range lists[1:] {
+		out = out.Intersection(other)
+	}
+	return out
+}
+
+// rank orders ids by their BM25 score for the terms of the query, best first and by ID
+// among equal scores. The last term, matched as a prefix, scores as the best-scoring
+// term of the book that it starts.
+func (ix *textIndex) rank(ids []string, clauses [][]string) []string {
+	terms := make(map[string]struct{})
+	for _, clause := range clauses {
+		for _, term := range clause {
+			terms[term] = struct{}{}
+		}
+	}
+	lastClause := clauses[len(clauses)-1]
+	prefix := lastClause[len(lastClause)-1]
+	delete(terms, prefix)
+	scores := make(map[string]float64, len(ids))
+	for _, id := range ids {
+		for term := range terms {
+			scores[id] += ix.score(term, id)
+		}
+		best := 0.0
+		for term := range ix.docs[id] {
+			if strings.HasPrefix(term, prefix) {
+				best = max(best, ix.score(term, id))
+			}
+		}
+		scores[id] += best
+	}
+	sort.SliceStable(ids, func(i, j int) bool { return scores[ids[i]] > scores[ids[j]] })
+	return ids
+}
+
+func (ix *textIndex) score(term, id string) float64 {
+	tf := float64(ix.docs[id][term])
+	if tf == 0 {
+		return 0
+	}
+	posting, _ := ix.postings.Get(term)
+	n, df := float64(len(ix.docs)), float64(posting.Size())
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+	length := 0
+	for _, f := range ix.docs[id] {
+		length += f
+	}
+	avgLength := float64(ix.totalLength) / n
+	norm := 1 - bm25B + bm25B*float64(length)/avgLength
+	return idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+}
+
+// tokenize splits text into folded terms (see foldText) made of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(foldText(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// parseQuery reads a search query as clauses separated by the keyword OR; the terms of
+// each clause must all appear. "go concurrency OR rust" matches books with both "go"
+// and "concurrency", or with "rust".
+func parseQuery(q string) [][]string {
+	clauses := make([][]string, 0)
+	var current []string
+	flush := func() {
+		if len(current) > 0 {
+			clauses = append(clauses, current)
+		}
+		current = nil
+	}
+	for _, word := range strings.Fields(q) {
+		if word == "OR" {
+			flush()
+			continue
+		}
+		current = append(current, tokenize(word)...)
+	}
+	flush()
+	return clauses
+}
+
+// queryKey is the normalized form of a parsed query, used as its search cache key.
+func queryKey(clauses [][]string) string {
+	parts := make([]string, len(clauses))
+	for i, clause := range clauses {
+		parts[i] = strings.Join(clause, " ")
+	}
+	return strings.Join(parts, " OR ")
+}
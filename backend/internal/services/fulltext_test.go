@@ -0,0 +1,169 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"library/internal/models"
+)
+
+func searchIDs(s *LibraryService, q string) []string {
+	return bookIDs(s.SearchBooks(q))
+}
+
+func bookIDs(books []models.Book) []string {
+	out := make([]string, 0, len(books))
+	for _, b := range books {
+		out = append(out, b.ID)
+	}
+	return out
+}
+
+func TestFullTextSearchAndOr(t *testing.T) {
+	s := NewLibraryService()
+	s.AddBook(models.Book{ID: "b1", Title: "The Go Programming Language", Author: "Donovan, Kernighan"})
+	s.AddBook(models.Book{ID: "b2", Title: "Concurrency in Go", Author: "Katherine Cox-Buday"})
+	s.AddBook(models.Book{ID: "b3", Title: "Programming Rust", Author: "Blandy"})
+	s.AddBook(models.Book{ID: "b4", Title: "The C Programming Language", Author: "Kernighan, Ritchie"})
+
+	if got := searchIDs(s, "language kernighan go"); !equalIDs(got, []string{"b1"}) {
+		t.Fatalf("terms in any order should all be required: %v", got)
+	}
+	if got := searchIDs(s, "Go concurrency OR rust"); !equalIDs(got, []string{"b2", "b3"}) {
+		t.Fatalf("unexpected OR results: %v", got)
+	}
+	if got := searchIDs(s, "python"); len(got) != 0 {
+		t.Fatalf("unknown term should match nothing: %v", got)
+	}
+
+	// "go" is in fewer books than "programming", so it weighs more in the score.
+	got := searchIDs(s, "go OR programming")
+	if len(got) != 4 || got[2] != "b3" && got[2] != "b4" || got[3] != "b3" && got[3] != "b4" {
+		t.Fatalf("books matching the rarer term should rank first: %v", got)
+	}
+
+	s.RemoveBook("b1")
+	s.AddBook(models.Book{ID: "b2", Title: "Concurrency Patterns", Author: "Katherine Cox-Buday"})
+	if got := searchIDs(s, "go"); len(got) != 0 {
+		t.Fatalf("removed and replaced books should leave the index: %v", got)
+	}
+}
+
+func TestLastQueryTermMatchesAsPrefix(t *testing.T) {
+	s := NewLibraryService()
+	s.AddBook(models.Book{ID: "b1", Title: "Cien años de soledad", Author: "Gabriel García Márquez"})
+	s.AddBook(models.Book{ID: "b2", Title: "Garbage Collection", Author: "Richard Jones"})
+	s.AddBook(models.Book{ID: "b3", Title: "Crónica de una muerte anunciada", Author: "Gabriel Garcia Marquez"})
+
+	if got := searchIDs(s, "gar"); len(got) != 3 {
+		t.Fatalf("a partial word should match every word it starts: %v", got)
+	}
+	if got := searchIDs(s, "cien gar"); !equalIDs(got, []string{"b1"}) {
+		t.Fatalf("earlier terms are still whole words: %v", got)
+	}
+	if got := searchIDs(s, "gar cien"); len(got) != 0 {
+		t.Fatalf("only the last term is a prefix: %v", got)
+	}
+	if got := searchIDs(s, "soledad OR garba"); !equalIDs(got, []string{"b1", "b2"}) && !equalIDs(got, []string{"b2", "b1"}) {
+		t.Fatalf("the prefix applies to the last clause: %v", got)
+	}
+	if got := searchIDs(s, "garbage"); !equalIDs(got, []string{"b2"}) {
+		t.Fatalf("a whole word still matches: %v", got)
+	}
+}
+
+func TestShortPrefixOnLargeCatalog(t *testing.T) {
+	s := NewLibraryService()
+	const books = 20000
+	for i := 0; i < books; i++ {
+		// Every book has its own words, so "a" starts thousands of terms.
+		s.AddBook(models.Book{ID: fmt.Sprintf("b%05d", i), Title: fmt.Sprintf("a%dx b%dy", i, i), Author: fmt.Sprintf("c%dz", i)})
+	}
+	start := time.Now()
+	got := s.SearchBooks("a")
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("a one-letter prefix took %v", elapsed)
+	}
+	if len(got) != books {
+		t.Fatalf("expected every book, got %d", len(got))
+	}
+	if got := searchIDs(s, "b123y a"); !equalIDs(got, []string{"b00123"}) {
+		t.Fatalf("unexpected results: %v", got)
+	}
+}
+
+func TestSearchFiltersIntersect(t *testing.T) {
+	s := NewLibraryService()
+	s.AddUser(models.User{ID: "u1", Name: "Ana"})
+	s.AddBook(models.Book{ID: "b1", Title: "The Go Programming Language", Author: "Alan Donovan"})
+	s.AddBook(models.Book{ID: "b2", Title: "The C Programming Language", Author: "Brian Kernighan"})
+	s.AddBook(models.Book{ID: "b3", Title: "The Practice of Programming", Author: "Brian Kernighan"})
+	if err := s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b2"}); err != nil {
+		t.Fatalf("borrow: %v", err)
+	}
+
+	if got := bookIDs(s.SearchBooksFiltered("programming", BookFilter{Author: "kernighan"})); !equalIDs(got, []string{"b2", "b3"}) {
+		t.Fatalf("query and author: %v", got)
+	}
+	if got := bookIDs(s.SearchBooksFiltered("programming", BookFilter{Author: "kernighan", AvailableOnly: true})); !equalIDs(got, []string{"b3"}) {
+		t.Fatalf("query, author and availability: %v", got)
+	}
+	if got := bookIDs(s.SearchBooksFiltered("", BookFilter{AvailableOnly: true})); !equalIDs(got, []string{"b1", "b3"}) {
+		t.Fatalf("availability alone: %v", got)
+	}
+	// "language" is in the titles of b1 and b2, but not in any author.
+	if got := bookIDs(s.SearchBooksFiltered("", BookFilter{Author: "language"})); len(got) != 0 {
+		t.Fatalf("author filter should only look at authors: %v", got)
+	}
+
+	if err := s.Return(models.LoanRequest{UserID: "u1", BookID: "b2"}); err != nil {
+		t.Fatalf("return: %v", err)
+	}
+	if got := bookIDs(s.SearchBooksFiltered("", BookFilter{AvailableOnly: true})); len(got) != 3 {
+		t.Fatalf("returned book should be available again: %v", got)
+	}
+}
+
+func TestFoldText(t *testing.T) {
+	cases := map[string]string{
+		"García Márquez": "garcia marquez",
+		"NIÑO":           "nino",
+		"Straße":         "strasse",
+		"Ça ira":         "ca ira",
+		"cafe\u0301":     "cafe", // combining acute accent
+		"Łódź":           "łodz", // stroke letters have no decomposition
+	}
+	for in, want := range cases {
+		if got := foldText(in); got != want {
+			t.Errorf("foldText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAccentInsensitiveMatching(t *testing.T) {
+	s := NewLibraryService()
+	s.AddBook(models.Book{ID: "b1", Title: "Cien años de soledad", Author: "Gabriel García Márquez", ISBN: "978-0-06-088328-7"})
+	s.AddBook(models.Book{ID: "b2", Title: "El niño con el pijama de rayas", Author: "John Boyne"})
+	s.AddBook(models.Book{ID: "b3", Title: "Crónica de una muerte anunciada", Author: "Gabriel Garcia Marquez"})
+
+	if got := searchIDs(s, "garcia marquez"); !equalIDs(got, []string{"b1", "b3"}) {
+		t.Fatalf("accents should not matter: %v", got)
+	}
+	if got := searchIDs(s, "NINO"); !equalIDs(got, []string{"b2"}) {
+		t.Fatalf("ñ should match n: %v", got)
+	}
+	if got := bookIDs(s.SearchBooksFiltered("", BookFilter{Author: "MÁRQUEZ"})); !equalIDs(got, []string{"b1", "b3"}) {
+		t.Fatalf("author filter should fold too: %v", got)
+	}
+
+	got := s.Autocomplete("gabriel garcía", 5)
+	if len(got) != 1 || !equalIDs(got[0].BookIDs, []string{"b1", "b3"}) {
+		t.Fatalf("both spellings should share one completion: %+v", got)
+	}
+
+	result, _ := s.ImportBooks([]models.Book{{ID: "b4", Title: "Cien Años", Author: "GGM", ISBN: "9780060883287"}})
+	if len(result.Rejected) != 1 {
+		t.Fatalf("ISBN written differently should still be a duplicate: %+v", result)
+	}
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"library/internal/ds"
+	"library/internal/models"
+	"library/internal/storage"
+)
+
+// PlaceHold puts the user at the back of the book's hold queue and returns the hold
+// with its position. Books with a copy on the shelf must be borrowed instead.
+func (s *LibraryService) PlaceHold(userID, bookID string) (models.Hold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users.Get(userID); !ok {
+		return models.Hold{}, errors.New("user not found")
+	}
+	book, ok := s.books.Get(bookID)
+	if !ok {
+		return models.Hold{}, errors.New("book not found")
+	}
+	if book.Available {
+		return models.Hold{}, errors.New("book is available")
+	}
+	if _, err := s.findLoan(userID, bookID); err == nil {
+		return models.Hold{}, errors.New("user already has the book")
+	}
+	if s.findHold(userID, bookID) != nil {
+		return models.Hold{}, errors.New("hold already placed")
+	}
+	s.holdSeq++
+	hold := models.Hold{
+		ID:       fmt.Sprintf("%012d", s.holdSeq),
+		UserID:   userID,
+		BookID:   bookID,
+		PlacedAt: s.clock.Now(),
+	}
+	var batch storage.Batch
+	if err := putRecord(&batch, holdPrefix, holdKey(hold), hold); err != nil {
+		return models.Hold{}, err
+	}
+	if err := s.persist(&batch); err != nil {
+		return models.Hold{}, err
+	}
+	s.enqueueHold(hold)
+	s.history.Push("hold:" + userID + ":" + bookID)
+	hold.Position = s.holds[bookID].Size()
+	return hold, nil
+}
+
+// CancelHold takes the user out of the book's queue. Cancelling a ready hold passes
+// the copy set aside on to the next patron waiting, or back to the shelf.
+func (s *LibraryService) CancelHold(userID, bookID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	node := s.findHold(userID, bookID)
+	if node == nil {
+		return errors.New("hold not found")
+	}
+	var batch storage.Batch
+	deleteRecord(&batch, holdPrefix, holdKey(node.Value))
+	apply := func() {}
+	if node.Value.ReadyAt != nil {
+		book, ok := s.books.Get(bookID)
+		if !ok {
+			return errors.New("book not found")
+		}
+		c, ok := s.copies.Get(node.Value.Barcode)
+		if !ok {
+			return errors.New("copy not found")
+		}
+		var err error
+		if apply, err = s.releaseCopy(&batch, book, c); err != nil {
+			return err
+		}
+	}
+	if err := s.persist(&batch); err != nil {
+		return err
+	}
+	s.removeHold(node)
+	apply()
+	s.history.Push("cancel_hold:" + userID + ":" + bookID)
+	return nil
+}
+
+// UserHolds lists the user's holds in book ID order, each with its queue position.
+func (s *LibraryService) UserHolds(userID string) []models.Hold {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]models.Hold, 0)
+	for _, bookID := range s.heldBooks() {
+		position := 0
+		s.holds[bookID].ForEach(func(h models.Hold) {
+			position++
+			if h.UserID == userID {
+				h.Position = position
+				out = append(out, h)
+			}
+		})
+	}
+	return out
+}
+
+// BookHolds lists the queue of a book, front first.
+func (s *LibraryService) BookHolds(bookID string) []models.Hold {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]models.Hold, 0)
+	if queue, ok := s.holds[bookID]; ok {
+		queue.ForEach(func(h models.Hold) {
+			h.Position = len(out) + 1
+			out = append(out, h)
+		})
+	}
+	return out
+}
+
+// frontHold returns the first hold in the book's queue, or nil.
+func (s *LibraryService) frontHold(bookID string) *ds.ListNode[models.Hold] {
+	if queue, ok := s.holds[bookID]; ok {
+		return queue.Front()
+	}
+	return nil
+}
+
+// waitingHold returns the first hold in the book's queue that is not ready yet, or nil.
+func (s *LibraryService) waitingHold(bookID string) *ds.ListNode[models.Hold] {
+	queue, ok := s.holds[bookID]
+	if !ok {
+		return nil
+	}
+	return queue.FindNode(func(h models.Hold) bool { return h.ReadyAt == nil })
+}
+
+func (s *LibraryService) findHold(userID, bookID string) *ds.ListNode[models.Hold] {
+	queue, ok := s.holds[bookID]
+	if !ok {
+		return nil
+	}
+	return queue.FindNode(func(h models.Hold) bool { return h.UserID == userID })
+}
+
+func (s *LibraryService) hasHolds(userID string) bool {
+	for _, queue := range s.holds {
+		if _, ok := queue.Find(func(h models.Hold) bool { return h.UserID == userID }); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueueHold appends the hold to its book's queue. Queues are linked lists rather than
+// ds.Queue because a cancelled hold leaves from the middle, which the node handle makes
+// O(1).
+func (s *LibraryService) enqueueHold(h models.Hold) {
+	queue, ok := s.holds[h.BookID]
+	if !ok {
+		queue = ds.NewList[models.Hold]()
+		s.holds[h.BookID] = queue
+	}
+	queue.InsertBack(h)
+}
+
+func (s *LibraryService) removeHold(node *ds.ListNode[models.Hold]) {
+	bookID := node.Value.BookID
+	queue := s.holds[bookID]
+	queue.Remove(node)
+	if queue.Size() == 0 {
+		delete(s.holds, bookID)
+	}
+}
+
+// heldBooks returns the IDs of the books with a hold queue, sorted.
+func (s *LibraryService) heldBooks() []string {
+	ids := make([]string, 0, len(s.holds))
+	for id := range s.holds {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// holdKey orders a book's holds by ID, which grows with every hold placed, so the store
+// returns each queue front first.
+func holdKey(h models.Hold) string {
+	return h.BookID + "/" + h.ID
+}
+
+// restoreHoldSeq continues hold IDs after the highest one in the queues.
+func (s *LibraryService) restoreHoldSeq() {
+	s.holdSeq = 0
+	for _, queue := range s.holds {
+		queue.ForEach(func(h models.Hold) {
+			if n, err := strconv.ParseUint(h.ID, 10, 64); err == nil {
+				s.holdSeq = max(s.holdSeq, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"library/internal/models"
+	"library/internal/storage"
+)
+
+func TestHoldQueueSetsBookAside(t *testing.T) {
+	s, _ := newClockedService(t)
+	s.AddUser(models.User{ID: "u3", Name: "Eva"})
+	if _, err := s.PlaceHold("u2", "b1"); err == nil {
+		t.Fatalf("holds on a book on the shelf should be refused")
+	}
+	s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"})
+	if _, err := s.PlaceHold("u1", "b1"); err == nil {
+		t.Fatalf("the borrower cannot hold their own book")
+	}
+	first, err := s.PlaceHold("u2", "b1")
+	if err != nil || first.Position != 1 {
+		t.Fatalf("first hold: %+v, %v", first, err)
+	}
+	second, _ := s.PlaceHold("u3", "b1")
+	if second.Position != 2 {
+		t.Fatalf("second hold should be behind the first: %+v", second)
+	}
+	if _, err := s.PlaceHold("u3", "b1"); err == nil {
+		t.Fatalf("duplicate hold should be refused")
+	}
+	if err := s.Renew("u1", "b1"); err == nil {
+		t.Fatalf("renewal should be refused while others wait")
+	}
+
+	if err := s.Return(models.LoanRequest{UserID: "u1", BookID: "b1"}); err != nil {
+		t.Fatalf("return: %v", err)
+	}
+	if got := searchIDs(s, "go"); len(got) != 1 || s.SearchBooks("go")[0].Available {
+		t.Fatalf("returned book should be set aside, not available")
+	}
+	holds := s.BookHolds("b1")
+	if len(holds) != 2 || holds[0].UserID != "u2" || holds[0].ReadyAt == nil || holds[1].ReadyAt != nil {
+		t.Fatalf("front hold should be ready: %+v", holds)
+	}
+	if err := s.Borrow(models.LoanRequest{UserID: "u3", BookID: "b1"}); err == nil {
+		t.Fatalf("only the patron at the front may borrow a set-aside book")
+	}
+
+	// u2 gives up; the book passes to u3, who then borrows it.
+	if err := s.CancelHold("u2", "b1"); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if got := s.UserHolds("u3"); len(got) != 1 || got[0].Position != 1 || got[0].ReadyAt == nil {
+		t.Fatalf("u3 should now be first and ready: %+v", got)
+	}
+	if err := s.Borrow(models.LoanRequest{UserID: "u3", BookID: "b1"}); err != nil {
+		t.Fatalf("borrow by holder: %v", err)
+	}
+	if got := s.BookHolds("b1"); len(got) != 0 {
+		t.Fatalf("hold should be consumed by the borrow: %+v", got)
+	}
+}
+
+func TestCancelReadyHoldReturnsBookToShelf(t *testing.T) {
+	s, _ := newClockedService(t)
+	s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"})
+	s.PlaceHold("u2", "b1")
+	s.Return(models.LoanRequest{UserID: "u1", BookID: "b1"})
+	if err := s.RemoveBook("b1"); err == nil {
+		t.Fatalf("books with holds should not be removable")
+	}
+	if err := s.RemoveUser("u2"); err == nil {
+		t.Fatalf("users with holds should not be removable")
+	}
+	if err := s.CancelHold("u2", "b1"); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if got := bookIDs(s.SearchBooksFiltered("", BookFilter{AvailableOnly: true})); !equalIDs(got, []string{"b1", "b2"}) {
+		t.Fatalf("book should be back on the shelf: %v", got)
+	}
+	if err := s.CancelHold("u2", "b1"); err == nil {
+		t.Fatalf("cancelling twice should fail")
+	}
+}
+
+func TestHoldsSurviveRestartAndSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "library.db")
+	store, err := storage.Open(path, storage.Options{})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	s := NewLibraryService()
+	if err := s.AttachStore(store); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	for _, id := range []string{"u1", "u2", "u3"} {
+		s.AddUser(models.User{ID: id, Name: id})
+	}
+	s.AddBook(models.Book{ID: "b1", Title: "Go", Author: "Gopher"})
+	s.AddBook(models.Book{ID: "b2", Title: "Rust", Author: "Ferris"})
+	s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"})
+	s.PlaceHold("u3", "b1")
+	s.PlaceHold("u2", "b1")
+	store.Close()
+
+	store, err = storage.Open(path, storage.Options{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer store.Close()
+	reloaded := NewLibraryService()
+	if err := reloaded.AttachStore(store); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	var buf bytes.Buffer
+	reloaded.Snapshot(&buf)
+	restored := NewLibraryService()
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	for _, svc := range []*LibraryService{reloaded, restored} {
+		holds := svc.BookHolds("b1")
+		if len(holds) != 2 || holds[0].UserID != "u3" || holds[1].UserID != "u2" {
+			t.Fatalf("queue order lost: %+v", holds)
+		}
+	}
+	reloaded.Borrow(models.LoanRequest{UserID: "u1", BookID: "b2"})
+	h, err := reloaded.PlaceHold("u2", "b2")
+	if err != nil || h.ID <= reloaded.BookHolds("b1")[1].ID {
+		t.Fatalf("hold IDs should continue after the loaded ones: %+v, %v", h, err)
+	}
+}
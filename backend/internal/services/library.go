@@ -2,126 +2,795 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"library/internal/ds"
 	"library/internal/models"
+	"library/internal/storage"
 )
 
+// historyCapacity is how many recent operations the service remembers; older ones are
+// overwritten.
+const historyCapacity = 1000
+
+// searchCacheSize is how many distinct search queries keep their results cached.
+const searchCacheSize = 256
+
+// isbnFilterSize and isbnFalsePositiveRate size the ISBN Bloom filter. It is rebuilt
+// with twice the room whenever the catalog outgrows it.
+const (
+	isbnFilterSize        = 10000
+	isbnFalsePositiveRate = 0.01
+)
+
+// IndexKind selects the ordered map that backs the books, copies, users and active loans
+// indexes.
+type IndexKind string
+
+const (
+	IndexAVL        IndexKind = "avl"
+	IndexRedBlack   IndexKind = "rbtree"
+	IndexUnbalanced IndexKind = "bst"
+	// IndexPersistent keeps every index as an immutable AVL whose versions are swapped
+	// atomically, so each read works on a point-in-time snapshot.
+	IndexPersistent IndexKind = "persistent"
+	IndexSkipList   IndexKind = "skiplist"
+)
+
+// LibraryService is safe for concurrent use. A single RWMutex guards every index:
+// writers (AddBook, Borrow, Return, ...) hold it exclusively so their check-then-update
+// sequences are atomic, and readers share it. The ds structures underneath are not
+// synchronized on their own, except that listings of IndexPersistent indexes only take
+// the lock to pick the current version (see readIndex).
 type LibraryService struct {
-	books       *ds.BST[string, models.Book]
-	users       *ds.BST[string, models.User]
-	activeLoans *ds.BST[string, models.LoanRequest]
-	history     *ds.Stack[string]
+	mu    sync.RWMutex
+	kind  IndexKind
+	books ds.OrderedMap[string, models.Book]
+	// copies holds every copy by barcode; bookCopies maps each book ID to its barcodes.
+	copies     ds.OrderedMap[string, models.Copy]
+	bookCopies map[string]*ds.Set[string]
+	users      ds.OrderedMap[string, models.User]
+	// activeLoans is keyed by the barcode of the loaned copy.
+	activeLoans ds.OrderedMap[string, models.Loan]
+	// returnedLoans holds finished loans as [BorrowedAt, ReturnedAt) ranges for
+	// LoansActiveAt.
+	returnedLoans *ds.IntervalTree[time.Time, models.Loan]
+	clock         Clock
+	// ledger holds every user's fines, payments and waivers keyed by "userID/entryID".
+	ledger    ds.OrderedMap[string, models.LedgerEntry]
+	ledgerSeq uint64
+	// holds maps each book ID to its FIFO of holds; books without holds have no entry.
+	holds       map[string]*ds.List[models.Hold]
+	holdSeq     uint64
+	history     *ds.RingBuffer[string]
 	featured    *ds.Array[string]
+	completions *ds.Trie[*completion]
+	// completionWords maps the text from each later word of a completion key to the end
+	// (every suffix starting at a word) to the keys it belongs to, so "marq" finds
+	// "Gabriel García Márquez".
+	completionWords *ds.Trie[map[string]struct{}]
+	text            *textIndex
+	authors         *textIndex
+	// isbns remembers every ISBN ever indexed so imports can skip the exact duplicate
+	// check for ISBNs that are certainly new. Removed books stay in it as false positives.
+	isbns *ds.BloomFilter[string]
+	// store is the optional durable copy of books, users and loans (see AttachStore).
+	store *storage.BTree
+
+	// searches caches SearchBooks results by normalized query. Lookups reorder the LRU,
+	// so it has its own mutex instead of relying on mu's shared read lock.
+	searchMu sync.Mutex
+	searches *ds.LRU[string, []models.Book]
+}
+
+// completion is the autocomplete index entry for one folded title or author (see
+// foldText), so spellings that differ only in accents or case share an entry. Text
+// keeps the spelling of the first book indexed under it.
+type completion struct {
+	text  string
+	books map[string]struct{}
 }
 
 func NewLibraryService() *LibraryService {
+	return NewLibraryServiceWithIndex(IndexAVL)
+}
+
+// ParseIndexKind validates an index name such as the LIBRARY_INDEX setting. An empty
+// name selects AVL; unknown names are an error.
+func ParseIndexKind(name string) (IndexKind, error) {
+	switch kind := IndexKind(strings.TrimSpace(name)); kind {
+	case "":
+		return IndexAVL, nil
+	case IndexAVL, IndexRedBlack, IndexUnbalanced, IndexPersistent, IndexSkipList:
+		return kind, nil
+	default:
+		return "", fmt.Errorf("unknown index kind %q", name)
+	}
+}
+
+// NewLibraryServiceWithIndex builds a service whose indexes use the given structure.
+// Unknown kinds fall back to AVL.
+func NewLibraryServiceWithIndex(kind IndexKind) *LibraryService {
+	switch kind {
+	case IndexRedBlack, IndexUnbalanced, IndexPersistent, IndexSkipList:
+	default:
+		kind = IndexAVL
+	}
 	return &LibraryService{
-		books:       ds.NewBST[string, models.Book](strings.Compare),
-		users:       ds.NewBST[string, models.User](strings.Compare),
-		activeLoans: ds.NewBST[string, models.LoanRequest](strings.Compare),
-		history:     ds.NewStack[string](),
-		featured:    ds.NewArray[string](5),
+		kind:            kind,
+		books:           newIndex[models.Book](kind),
+		copies:          newIndex[models.Copy](kind),
+		bookCopies:      make(map[string]*ds.Set[string]),
+		users:           newIndex[models.User](kind),
+		activeLoans:     newIndex[models.Loan](kind),
+		returnedLoans:   newLoanLog(),
+		ledger:          newIndex[models.LedgerEntry](kind),
+		holds:           make(map[string]*ds.List[models.Hold]),
+		clock:           systemClock{},
+		history:         ds.NewRingBuffer[string](historyCapacity),
+		featured:        ds.NewArray[string](5),
+		completions:     ds.NewTrie[*completion](),
+		completionWords: ds.NewTrie[map[string]struct{}](),
+		text:            newTextIndex(),
+		authors:         newTextIndex(),
+		isbns:           ds.NewBloomFilter[string](isbnFilterSize, isbnFalsePositiveRate),
+		searches:        ds.NewLRU[string, []models.Book](searchCacheSize, nil),
 	}
 }
 
-func (s *LibraryService) AddBook(b models.Book) {
-	b.Available = true
-	s.books.Put(b.ID, b)
+func newIndex[V any](kind IndexKind) ds.OrderedMap[string, V] {
+	switch kind {
+	case IndexRedBlack:
+		return ds.NewRBTree[string, V](strings.Compare)
+	case IndexUnbalanced:
+		return ds.NewUnbalancedBST[string, V](strings.Compare)
+	case IndexPersistent:
+		return ds.NewVersionedMap[string, V](strings.Compare)
+	case IndexSkipList:
+		return ds.NewSkipList[string, V](strings.Compare)
+	default:
+		return ds.NewBST[string, V](strings.Compare)
+	}
+}
+
+// loadIndex adds entries sorted by key to index. An empty AVL index is replaced by a
+// tree bulk-built from the entries in linear time; other kinds insert one by one.
+func loadIndex[V any](kind IndexKind, index ds.OrderedMap[string, V], keys []string, values []V) (ds.OrderedMap[string, V], error) {
+	if kind == IndexAVL && index.IsEmpty() {
+		return ds.BuildFromSorted(strings.Compare, keys, values)
+	}
+	for i, key := range keys {
+		index.Put(key, values[i])
+	}
+	return index, nil
+}
+
+// AddBook adds a book with b.Copies copies on the shelf, or one when it is zero. Adding
+// a book that already exists updates its details and keeps its copies. It returns the
+// book as stored, with its copy counts.
+func (s *LibraryService) AddBook(b models.Book) (models.Book, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var batch storage.Batch
+	b, copies, err := s.stockBook(&batch, b)
+	if err != nil {
+		return models.Book{}, err
+	}
+	if err := s.persist(&batch); err != nil {
+		return models.Book{}, err
+	}
+	s.addBook(b, copies)
+	return b, nil
+}
+
+func (s *LibraryService) addBook(b models.Book, copies []models.Copy) {
+	for _, c := range copies {
+		s.putCopy(c)
+	}
+	if previous, replaced := s.books.Put(b.ID, b); replaced {
+		s.unindexBook(previous)
+	}
+	s.indexBook(b)
+	s.invalidateSearches()
 	s.history.Push("add_book:" + b.ID)
 }
 
+// ImportBooks adds a batch of books, rejecting those with missing fields or whose ID or
+// ISBN already exists in the catalog or earlier in the same batch. The accepted books are
+// persisted together. The ISBN Bloom filter answers most lookups; only possible hits
+// fall back to scanning the catalog.
+func (s *LibraryService) ImportBooks(books []models.Book) (models.ImportResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := models.ImportResult{Imported: make([]string, 0), Rejected: make([]models.ImportRejected, 0)}
+	type stocked struct {
+		book   models.Book
+		copies []models.Copy
+	}
+	accepted := make([]stocked, 0, len(books))
+	inBatch := make(map[string]bool)
+	inBatchIDs := make(map[string]bool)
+	var batch storage.Batch
+	for _, b := range books {
+		reject := func(reason string) {
+			result.Rejected = append(result.Rejected, models.ImportRejected{ID: b.ID, ISBN: b.ISBN, Reason: reason})
+		}
+		if b.ID == "" || b.Title == "" || b.Author == "" {
+			reject("missing fields")
+			continue
+		}
+		if inBatchIDs[b.ID] {
+			reject("duplicate id in import")
+			continue
+		}
+		if s.books.Contains(b.ID) {
+			reject("id already in catalog")
+			continue
+		}
+		isbn := normalizeISBN(b.ISBN)
+		if isbn != "" && inBatch[isbn] {
+			reject("duplicate isbn in import")
+			continue
+		}
+		if isbn != "" && s.isbns.MayContain(isbn) && s.hasISBN(isbn) {
+			reject("isbn already in catalog")
+			continue
+		}
+		b, copies, err := s.stockBook(&batch, b)
+		if err != nil {
+			reject(err.Error())
+			continue
+		}
+		inBatch[isbn] = isbn != ""
+		inBatchIDs[b.ID] = true
+		accepted = append(accepted, stocked{b, copies})
+	}
+	if err := s.persist(&batch); err != nil {
+		return models.ImportResult{}, err
+	}
+	for _, a := range accepted {
+		s.addBook(a.book, a.copies)
+		result.Imported = append(result.Imported, a.book.ID)
+	}
+	return result, nil
+}
+
+// hasISBN is the exact duplicate check behind the Bloom filter.
+func (s *LibraryService) hasISBN(isbn string) bool {
+	found := false
+	s.books.Ascend(func(_ string, b models.Book) bool {
+		found = normalizeISBN(b.ISBN) == isbn
+		return !found
+	})
+	return found
+}
+
+// normalizeISBN drops hyphens and spaces so "978-0-13-110362-7" and "9780131103627"
+// compare equal.
+func normalizeISBN(isbn string) string {
+	return foldText(strings.NewReplacer("-", "", " ", "").Replace(isbn))
+}
+
+func (s *LibraryService) trackISBN(isbn string) {
+	if isbn == "" {
+		return
+	}
+	s.isbns.Add(isbn)
+	if !s.isbns.Saturated() {
+		return
+	}
+	s.isbns = ds.NewBloomFilter[string](max(2*s.books.Size(), isbnFilterSize), isbnFalsePositiveRate)
+	s.books.TraverseInOrder(func(_ string, b models.Book) {
+		if isbn := normalizeISBN(b.ISBN); isbn != "" {
+			s.isbns.Add(isbn)
+		}
+	})
+}
+
+func (s *LibraryService) indexBook(b models.Book) {
+	s.trackISBN(normalizeISBN(b.ISBN))
+	s.text.add(b.ID, append(tokenize(b.Title), tokenize(b.Author)...))
+	s.authors.add(b.ID, tokenize(b.Author))
+	for _, text := range []string{b.Title, b.Author} {
+		key := completionKey(text)
+		if key == "" {
+			continue
+		}
+		entry, ok := s.completions.Get(key)
+		if !ok {
+			entry = &completion{text: strings.TrimSpace(text), books: make(map[string]struct{})}
+			s.completions.Put(key, entry)
+			for _, suffix := range wordSuffixes(key) {
+				keys, ok := s.completionWords.Get(suffix)
+				if !ok {
+					keys = make(map[string]struct{})
+					s.completionWords.Put(suffix, keys)
+				}
+				keys[key] = struct{}{}
+			}
+		}
+		entry.books[b.ID] = struct{}{}
+	}
+}
+
+func (s *LibraryService) unindexBook(b models.Book) {
+	s.text.remove(b.ID)
+	s.authors.remove(b.ID)
+	for _, text := range []string{b.Title, b.Author} {
+		key := completionKey(text)
+		entry, ok := s.completions.Get(key)
+		if !ok {
+			continue
+		}
+		delete(entry.books, b.ID)
+		if len(entry.books) == 0 {
+			s.completions.Delete(key)
+			for _, suffix := range wordSuffixes(key) {
+				if keys, ok := s.completionWords.Get(suffix); ok {
+					delete(keys, key)
+					if len(keys) == 0 {
+						s.completionWords.Delete(suffix)
+					}
+				}
+			}
+		}
+	}
+}
+
+func completionKey(text string) string {
+	return foldText(strings.TrimSpace(text))
+}
+
+// wordSuffixes returns the suffixes of key that start at its second word or later:
+// "gabriel garcia marquez" gives "garcia marquez" and "marquez".
+func wordSuffixes(key string) []string {
+	words := strings.Fields(key)
+	out := make([]string, 0, len(words))
+	for i := 1; i < len(words); i++ {
+		out = append(out, strings.Join(words[i:], " "))
+	}
+	return out
+}
+
+// Autocomplete returns up to limit titles and authors with a word that starts with
+// prefix. Completions shared by more books come first; among equals, those that start
+// with prefix come before those that only match a later word, then alphabetical order.
+// The lookup only walks the matching branches of the tries.
+func (s *LibraryService) Autocomplete(prefix string, limit int) []models.Completion {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]models.Completion, 0)
+	key := completionKey(prefix)
+	if key == "" || limit <= 0 {
+		return out
+	}
+	type candidate struct {
+		key   string
+		entry *completion
+		whole bool
+	}
+	var found []candidate
+	seen := make(map[string]bool)
+	s.completions.WalkPrefix(key, func(k string, entry *completion) bool {
+		found = append(found, candidate{k, entry, true})
+		seen[k] = true
+		return true
+	})
+	s.completionWords.WalkPrefix(key, func(_ string, keys map[string]struct{}) bool {
+		for k := range keys {
+			if entry, ok := s.completions.Get(k); ok && !seen[k] {
+				found = append(found, candidate{k, entry, false})
+				seen[k] = true
+			}
+		}
+		return true
+	})
+	sort.Slice(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if len(a.entry.books) != len(b.entry.books) {
+			return len(a.entry.books) > len(b.entry.books)
+		}
+		if a.whole != b.whole {
+			return a.whole
+		}
+		return a.key < b.key
+	})
+	for _, c := range found[:min(limit, len(found))] {
+		ids := make([]string, 0, len(c.entry.books))
+		for id := range c.entry.books {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		out = append(out, models.Completion{Text: c.entry.text, BookIDs: ids})
+	}
+	return out
+}
+
 func (s *LibraryService) ListBooks() []models.Book {
-	out := make([]models.Book, 0, s.books.Size())
-	s.books.TraverseInOrder(func(_ string, v models.Book) { out = append(out, v) })
+	return readIndex(s, func() ds.OrderedMap[string, models.Book] { return s.books }, listAll[models.Book])
+}
+
+func (s *LibraryService) listBooks() []models.Book {
+	return listAll[models.Book](s.books)
+}
+
+// ListBooksPage returns up to limit books whose IDs sort after the given one, plus the
+// ID to pass as after for the next page (empty when there are no more books).
+func (s *LibraryService) ListBooksPage(after string, limit int) ([]models.Book, string) {
+	var next string
+	items := readIndex(s, func() ds.OrderedMap[string, models.Book] { return s.books }, func(index indexReader[models.Book]) []models.Book {
+		var items []models.Book
+		items, next = page(index, after, limit)
+		return items
+	})
+	return items, next
+}
+
+// indexReader is the read side shared by every ds.OrderedMap and by the versions of a
+// ds.VersionedMap.
+type indexReader[V any] interface {
+	Size() int
+	TraverseInOrder(fn func(key string, value V))
+	Ascend(fn func(key string, value V) bool)
+	AscendAfter(key string, fn func(key string, value V) bool)
+}
+
+// readIndex runs read over the index returned by pick. A persistent index is read from
+// its current version after mu is released, so long listings do not hold up writers
+// and still see a consistent state; other kinds are read under the shared lock.
+func readIndex[V, R any](s *LibraryService, pick func() ds.OrderedMap[string, V], read func(indexReader[V]) R) R {
+	s.mu.RLock()
+	index := pick()
+	if versioned, ok := index.(*ds.VersionedMap[string, V]); ok {
+		version := versioned.Snapshot()
+		s.mu.RUnlock()
+		return read(version)
+	}
+	defer s.mu.RUnlock()
+	return read(index)
+}
+
+func listAll[V any](index indexReader[V]) []V {
+	out := make([]V, 0, index.Size())
+	index.TraverseInOrder(func(_ string, v V) { out = append(out, v) })
 	return out
 }
 
+// BookFilter narrows a search. Zero fields do not filter.
+type BookFilter struct {
+	// Author keeps the books whose author contains every word of it.
+	Author        string
+	AvailableOnly bool
+}
+
+// key is appended to the query in the search cache key.
+func (f BookFilter) key() string {
+	key := ""
+	if terms := tokenize(f.Author); len(terms) > 0 {
+		key += " |author " + strings.Join(terms, " ")
+	}
+	if f.AvailableOnly {
+		key += " |available"
+	}
+	return key
+}
+
+// SearchBooks ranks the books whose title or author contains the query terms, using
+// the full-text index (see parseQuery for the AND/OR syntax). An empty query lists
+// every book.
 func (s *LibraryService) SearchBooks(q string) []models.Book {
-	q = strings.ToLower(strings.TrimSpace(q))
-	out := make([]models.Book, 0)
-	if q == "" {
-		return s.ListBooks()
+	return s.SearchBooksFiltered(q, BookFilter{})
+}
+
+// SearchBooksFiltered is SearchBooks restricted by filter. Each criterion yields an
+// ordered set of book IDs and the results are their intersection; books are ranked by
+// relevance when there is a query and sorted by ID otherwise.
+func (s *LibraryService) SearchBooksFiltered(q string, filter BookFilter) []models.Book {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	clauses := parseQuery(q)
+	key := queryKey(clauses) + filter.key()
+	if strings.TrimSpace(q) == "" && key == "" {
+		return s.listBooks()
+	}
+	s.searchMu.Lock()
+	cached, ok := s.searches.Get(key)
+	s.searchMu.Unlock()
+	if ok {
+		return slices.Clone(cached)
+	}
+
+	var matched *ds.Set[string]
+	narrow := func(ids *ds.Set[string]) {
+		if matched == nil {
+			matched = ids
+		} else {
+			matched = matched.Intersection(ids)
+		}
 	}
-	s.books.TraverseInOrder(func(_ string, v models.Book) {
-		if strings.Contains(strings.ToLower(v.Title), q) || strings.Contains(strings.ToLower(v.Author), q) {
-			out = append(out, v)
+	if strings.TrimSpace(q) != "" {
+		narrow(s.text.matches(clauses))
+	}
+	if terms := tokenize(filter.Author); len(terms) > 0 {
+		narrow(s.authors.matchAll(terms))
+	}
+	if filter.AvailableOnly {
+		narrow(s.availableBooks())
+	}
+
+	ids := matched.Items()
+	if len(clauses) > 0 {
+		ids = s.text.rank(ids, clauses)
+	}
+	out := make([]models.Book, 0, len(ids))
+	for _, id := range ids {
+		if b, ok := s.books.Get(id); ok {
+			out = append(out, b)
+		}
+	}
+	s.searchMu.Lock()
+	s.searches.Put(key, out)
+	s.searchMu.Unlock()
+	return slices.Clone(out)
+}
+
+// availableBooks is the set of the IDs of books with a copy on the shelf. The books
+// index is traversed in order, so the set is bulk-built in linear time.
+func (s *LibraryService) availableBooks() *ds.Set[string] {
+	ids := make([]string, 0, s.books.Size())
+	s.books.TraverseInOrder(func(id string, b models.Book) {
+		if b.Available {
+			ids = append(ids, id)
 		}
 	})
-	return out
+	set, _ := ds.NewSetFromSorted(strings.Compare, ids)
+	return set
 }
 
-func (s *LibraryService) AddUser(u models.User) {
+// invalidateSearches drops every cached search. Callers hold mu exclusively; any
+// change to a book's text or availability can alter the results of any query.
+func (s *LibraryService) invalidateSearches() {
+	s.searchMu.Lock()
+	s.searches.Purge()
+	s.searchMu.Unlock()
+}
+
+// AddUser adds or updates a user. IDs may not contain NUL, which separates the user ID
+// in ledger keys, nor be longer than MaxIDLength.
+func (s *LibraryService) AddUser(u models.User) error {
+	if strings.ContainsRune(u.ID, 0) {
+		return errors.New("invalid user id")
+	}
+	if len(u.ID) > MaxIDLength {
+		return fmt.Errorf("user id longer than %d bytes", MaxIDLength)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var batch storage.Batch
+	if err := putRecord(&batch, userPrefix, u.ID, u); err != nil {
+		return err
+	}
+	if err := s.persist(&batch); err != nil {
+		return err
+	}
 	s.users.Put(u.ID, u)
 	s.history.Push("add_user:" + u.ID)
+	return nil
 }
 
 func (s *LibraryService) ListUsers() []models.User {
-	out := make([]models.User, 0, s.users.Size())
-	s.users.TraverseInOrder(func(_ string, v models.User) { out = append(out, v) })
-	return out
+	return readIndex(s, func() ds.OrderedMap[string, models.User] { return s.users }, listAll[models.User])
+}
+
+// ListUsersPage is the users counterpart of ListBooksPage.
+func (s *LibraryService) ListUsersPage(after string, limit int) ([]models.User, string) {
+	var next string
+	items := readIndex(s, func() ds.OrderedMap[string, models.User] { return s.users }, func(index indexReader[models.User]) []models.User {
+		var items []models.User
+		items, next = page(index, after, limit)
+		return items
+	})
+	return items, next
 }
 
+// page walks the index from after (or from the start when after is empty) and stops
+// as soon as it has one entry more than limit, which tells whether a next page exists.
+func page[V any](index indexReader[V], after string, limit int) ([]V, string) {
+	out := make([]V, 0, limit)
+	next, last := "", ""
+	collect := func(key string, v V) bool {
+		if len(out) == limit {
+			next = last
+			return false
+		}
+		out = append(out, v)
+		last = key
+		return true
+	}
+	if after == "" {
+		index.Ascend(collect)
+	} else {
+		index.AscendAfter(after, collect)
+	}
+	return out, next
+}
+
+// Borrow lends a copy of the book to the user for loanPeriod, starting now by the
+// service clock. A user whose hold is ready gets the copy set aside for them; anyone
+// else gets the first copy on the shelf.
 func (s *LibraryService) Borrow(req models.LoanRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if _, ok := s.users.Get(req.UserID); !ok {
 		return errors.New("user not found")
 	}
+	if s.balance(req.UserID) > borrowLimit {
+		return ErrUnpaidFines
+	}
 	book, ok := s.books.Get(req.BookID)
 	if !ok {
 		return errors.New("book not found")
 	}
-	if !book.Available {
-		return errors.New("book not available")
+	if _, err := s.findLoan(req.UserID, req.BookID); err == nil {
+		return errors.New("user already has the book")
+	}
+	var c models.Copy
+	hold := s.findHold(req.UserID, req.BookID)
+	if hold != nil && hold.Value.ReadyAt != nil {
+		if c, ok = s.copies.Get(hold.Value.Barcode); !ok {
+			return errors.New("copy not found")
+		}
+	} else {
+		hold = nil
+		if c, ok = s.shelfCopy(req.BookID); !ok {
+			if front := s.frontHold(req.BookID); front != nil && front.Value.ReadyAt != nil {
+				return errors.New("book on hold for another patron")
+			}
+			return errors.New("book not available")
+		}
+	}
+	book = recount(book, c.Status, models.CopyLoaned)
+	c.Status = models.CopyLoaned
+	now := s.clock.Now()
+	loan := models.Loan{UserID: req.UserID, BookID: req.BookID, Barcode: c.Barcode, BorrowedAt: now, DueAt: now.Add(loanPeriod)}
+	var batch storage.Batch
+	if hold != nil {
+		deleteRecord(&batch, holdPrefix, holdKey(hold.Value))
+	}
+	if err := putRecord(&batch, bookPrefix, book.ID, book); err != nil {
+		return err
 	}
-	if _, exists := s.activeLoans.Get(req.BookID); exists {
-		return errors.New("book already loaned")
+	if err := putRecord(&batch, copyPrefix, c.Barcode, c); err != nil {
+		return err
+	}
+	if err := putRecord(&batch, loanPrefix, c.Barcode, loan); err != nil {
+		return err
+	}
+	if err := s.persist(&batch); err != nil {
+		return err
 	}
-	book.Available = false
 	s.books.Put(book.ID, book)
-	s.activeLoans.Put(req.BookID, req)
+	s.putCopy(c)
+	s.invalidateSearches()
+	s.activeLoans.Put(c.Barcode, loan)
+	if hold != nil {
+		s.removeHold(hold)
+	}
 	s.history.Push("borrow:" + req.UserID + ":" + req.BookID)
 	return nil
 }
 
+// Return ends the user's loan of a copy of the book. A late return adds a fine to the
+// user's ledger. If patrons are waiting, the copy is set aside for the first hold in
+// line instead of going back on the shelf.
 func (s *LibraryService) Return(req models.LoanRequest) error {
-	loan, ok := s.activeLoans.Get(req.BookID)
-	if !ok {
-		return errors.New("loan not found")
-	}
-	if loan.UserID != req.UserID {
-		return errors.New("loan belongs to a different user")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	loan, err := s.findLoan(req.UserID, req.BookID)
+	if err != nil {
+		return err
 	}
 	book, ok := s.books.Get(req.BookID)
 	if !ok {
 		return errors.New("book not found")
 	}
-	book.Available = true
-	s.books.Put(book.ID, book)
-	s.activeLoans.Delete(req.BookID)
+	c, ok := s.copies.Get(loan.Barcode)
+	if !ok {
+		return errors.New("copy not found")
+	}
+	returnedAt := s.clock.Now()
+	loan.ReturnedAt = &returnedAt
+	var batch storage.Batch
+	release, err := s.releaseCopy(&batch, book, c)
+	if err != nil {
+		return err
+	}
+	deleteRecord(&batch, loanPrefix, loan.Barcode)
+	if err := putRecord(&batch, returnedPrefix, returnedLoanID(loan), loan); err != nil {
+		return err
+	}
+	var fine *models.LedgerEntry
+	if amount := lateFine(loan, returnedAt); amount > 0 {
+		entry := s.newLedgerEntry(req.UserID, models.EntryFine, amount)
+		entry.BookID = req.BookID
+		if err := putRecord(&batch, finePrefix, ledgerKey(entry), entry); err != nil {
+			return err
+		}
+		fine = &entry
+	}
+	if err := s.persist(&batch); err != nil {
+		return err
+	}
+	if fine != nil {
+		s.ledger.Put(ledgerKey(*fine), *fine)
+	}
+	release()
+	s.activeLoans.Delete(loan.Barcode)
+	s.logReturnedLoan(loan)
 	s.history.Push("return:" + req.UserID + ":" + req.BookID)
 	return nil
 }
 
-func (s *LibraryService) HistorySize() int { return s.history.Size() }
+func (s *LibraryService) HistorySize() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.history.Size()
+}
 
-// RemoveBook deletes a book by ID. It refuses to delete if the book is currently loaned (Available=false).
+// History returns up to limit recent operations, newest first.
+func (s *LibraryService) History(limit int) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.history.Newest(limit)
+}
+
+// RemoveBook deletes a book by ID together with its copies. It refuses to delete if a
+// copy is currently loaned.
 func (s *LibraryService) RemoveBook(id string) error {
-	if _, active := s.activeLoans.Get(id); active {
-		return errors.New("book currently loaned")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	barcodes := s.barcodes(id)
+	for _, barcode := range barcodes {
+		if s.activeLoans.Contains(barcode) {
+			return errors.New("book currently loaned")
+		}
+	}
+	if _, held := s.holds[id]; held {
+		return errors.New("book has holds")
 	}
-	_, deleted := s.books.Delete(id)
-	if !deleted {
+	if !s.books.Contains(id) {
 		return errors.New("book not found")
 	}
+	var batch storage.Batch
+	deleteRecord(&batch, bookPrefix, id)
+	for _, barcode := range barcodes {
+		deleteRecord(&batch, copyPrefix, barcode)
+	}
+	if err := s.persist(&batch); err != nil {
+		return err
+	}
+	for _, barcode := range barcodes {
+		s.copies.Delete(barcode)
+	}
+	delete(s.bookCopies, id)
+	removed, _ := s.books.Delete(id)
+	s.unindexBook(removed)
+	s.invalidateSearches()
 	s.history.Push("remove_book:" + id)
 	return nil
 }
 
 // RemoveUser deletes a user by ID.
 func (s *LibraryService) RemoveUser(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	hasLoans := false
-	s.activeLoans.TraverseInOrder(func(_ string, loan models.LoanRequest) {
+	s.activeLoans.TraverseInOrder(func(_ string, loan models.Loan) {
 		if loan.UserID == id {
 			hasLoans = true
 		}
@@ -129,10 +798,46 @@ func (s *LibraryService) RemoveUser(id string) error {
 	if hasLoans {
 		return errors.New("user has active loans")
 	}
-	_, deleted := s.users.Delete(id)
-	if !deleted {
+	if s.balance(id) != 0 {
+		return errors.New("user has unpaid fines")
+	}
+	if s.hasHolds(id) {
+		return errors.New("user has holds")
+	}
+	if !s.users.Contains(id) {
 		return errors.New("user not found")
 	}
+	var batch storage.Batch
+	deleteRecord(&batch, userPrefix, id)
+	if err := s.persist(&batch); err != nil {
+		return err
+	}
+	s.users.Delete(id)
 	s.history.Push("remove_user:" + id)
 	return nil
 }
+
+// IndexStats reports the size and shape of the books, copies, users and active loans
+// indexes, which shows when an unbalanced index is degrading towards a list.
+func (s *LibraryService) IndexStats() []models.IndexStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return []models.IndexStats{
+		indexStats("books", s.kind, s.books),
+		indexStats("copies", s.kind, s.copies),
+		indexStats("users", s.kind, s.users),
+		indexStats("loans", s.kind, s.activeLoans),
+	}
+}
+
+func indexStats[V any](name string, kind IndexKind, index ds.OrderedMap[string, V]) models.IndexStats {
+	stats := models.IndexStats{Name: name, Kind: string(kind), Size: index.Size()}
+	switch index := index.(type) {
+	case *ds.BST[string, V]:
+		shape := index.Stats()
+		stats.Height, stats.AverageDepth, stats.Leaves = shape.Height, shape.AverageDepth, shape.Leaves
+	case interface{ Height() int }:
+		stats.Height = index.Height()
+	}
+	return stats
+}
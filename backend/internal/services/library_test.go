@@ -1,7 +1,10 @@
 package services
 
 import (
+	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"library/internal/models"
@@ -23,6 +26,18 @@ func TestBorrowReturnFlow(t *testing.T) {
 	}
 }
 
+func TestParseIndexKind(t *testing.T) {
+	if kind, err := ParseIndexKind(""); err != nil || kind != IndexAVL {
+		t.Fatalf("empty should select AVL: %q, %v", kind, err)
+	}
+	if kind, err := ParseIndexKind("skiplist"); err != nil || kind != IndexSkipList {
+		t.Fatalf("unexpected kind: %q, %v", kind, err)
+	}
+	if _, err := ParseIndexKind("btree"); err == nil {
+		t.Fatalf("unknown kinds should be rejected")
+	}
+}
+
 func TestBorrowRequiresUserAndBook(t *testing.T) {
 	s := NewLibraryService()
 	s.AddBook(models.Book{ID: "b1", Title: "Go", Author: "Gopher"})
@@ -104,3 +119,327 @@ func TestSearchBooks(t *testing.T) {
 		t.Fatalf("empty search should return all books")
 	}
 }
+
+func TestConcurrentBorrowOfSameBook(t *testing.T) {
+	s := NewLibraryService()
+	s.AddBook(models.Book{ID: "b1", Title: "Go", Author: "Gopher"})
+	const patrons = 200
+	for i := 0; i < patrons; i++ {
+		s.AddUser(models.User{ID: fmt.Sprintf("u%d", i), Name: "N"})
+	}
+
+	var wg sync.WaitGroup
+	var borrowed atomic.Int32
+	for i := 0; i < patrons; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if s.Borrow(models.LoanRequest{UserID: fmt.Sprintf("u%d", i), BookID: "b1"}) == nil {
+				borrowed.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if borrowed.Load() != 1 {
+		t.Fatalf("expected exactly one successful borrow, got %d", borrowed.Load())
+	}
+}
+
+func TestConcurrentBorrowReturnCycles(t *testing.T) {
+	for _, kind := range []IndexKind{IndexAVL, IndexPersistent} {
+		t.Run(string(kind), func(t *testing.T) { testConcurrentBorrowReturnCycles(t, kind) })
+	}
+}
+
+func testConcurrentBorrowReturnCycles(t *testing.T, kind IndexKind) {
+	s := NewLibraryServiceWithIndex(kind)
+	const books, rounds = 20, 300
+	for i := 0; i < books; i++ {
+		s.AddBook(models.Book{ID: fmt.Sprintf("b%d", i), Title: "Go", Author: "Gopher"})
+		s.AddUser(models.User{ID: fmt.Sprintf("u%d", i), Name: "N"})
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < rounds; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			req := models.LoanRequest{UserID: fmt.Sprintf("u%d", i%books), BookID: fmt.Sprintf("b%d", i%books)}
+			if s.Borrow(req) == nil {
+				if err := s.Return(req); err != nil {
+					t.Errorf("return after successful borrow: %v", err)
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			s.SearchBooks("go")
+			s.ListBooksPage("", 5)
+			for _, l := range s.ListLoans() {
+				if l.Barcode == "" {
+					t.Errorf("listed a loan without a copy: %+v", l)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, b := range s.ListBooks() {
+		if !b.Available {
+			t.Fatalf("book %s left unavailable after all returns", b.ID)
+		}
+	}
+}
+
+func BenchmarkCatalogLoad(b *testing.B) {
+	for _, kind := range []IndexKind{IndexUnbalanced, IndexAVL, IndexRedBlack, IndexPersistent, IndexSkipList} {
+		b.Run(string(kind), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s := NewLibraryServiceWithIndex(kind)
+				for j := 0; j < 2000; j++ {
+					s.AddBook(models.Book{ID: fmt.Sprintf("b%05d", j), Title: "T", Author: "A"})
+				}
+				for j := 0; j < 2000; j++ {
+					s.books.Get(fmt.Sprintf("b%05d", j))
+				}
+			}
+		})
+	}
+}
+
+func TestListBooksPage(t *testing.T) {
+	s := NewLibraryService()
+	for i := 1; i <= 5; i++ {
+		s.AddBook(models.Book{ID: fmt.Sprintf("b%d", i), Title: "T", Author: "A"})
+	}
+
+	page, next := s.ListBooksPage("", 2)
+	if len(page) != 2 || page[0].ID != "b1" || next != "b2" {
+		t.Fatalf("unexpected first page: %+v next=%q", page, next)
+	}
+	page, next = s.ListBooksPage(next, 2)
+	if len(page) != 2 || page[0].ID != "b3" || next != "b4" {
+		t.Fatalf("unexpected second page: %+v next=%q", page, next)
+	}
+	page, next = s.ListBooksPage(next, 2)
+	if len(page) != 1 || page[0].ID != "b5" || next != "" {
+		t.Fatalf("unexpected last page: %+v next=%q", page, next)
+	}
+}
+
+func TestAutocompleteTracksAddAndRemove(t *testing.T) {
+	s := NewLibraryService()
+	s.AddBook(models.Book{ID: "b1", Title: "Cien años de soledad", Author: "Gabriel García Márquez"})
+	s.AddBook(models.Book{ID: "b2", Title: "Crónica de una muerte anunciada", Author: "Gabriel García Márquez"})
+	s.AddBook(models.Book{ID: "b3", Title: "Go Programming", Author: "Gopher"})
+
+	got := s.Autocomplete("GAB", 10)
+	if len(got) != 1 || got[0].Text != "Gabriel García Márquez" || len(got[0].BookIDs) != 2 {
+		t.Fatalf("unexpected author completion: %+v", got)
+	}
+	if got := s.Autocomplete("c", 1); len(got) != 1 || got[0].Text != "Cien años de soledad" {
+		t.Fatalf("unexpected limited completion: %+v", got)
+	}
+
+	if err := s.RemoveBook("b1"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if got := s.Autocomplete("cien", 10); len(got) != 0 {
+		t.Fatalf("removed title still suggested: %+v", got)
+	}
+	if got := s.Autocomplete("gab", 10); len(got) != 1 || len(got[0].BookIDs) != 1 {
+		t.Fatalf("author should keep the remaining book: %+v", got)
+	}
+
+	s.AddBook(models.Book{ID: "b3", Title: "Rust", Author: "Ferris"})
+	if got := s.Autocomplete("go", 10); len(got) != 0 {
+		t.Fatalf("replaced book still indexed under old title: %+v", got)
+	}
+}
+
+func TestAutocompleteRanksAndMatchesLaterWords(t *testing.T) {
+	s := NewLibraryService()
+	s.AddBook(models.Book{ID: "b1", Title: "Cien años de soledad", Author: "Gabriel García Márquez"})
+	s.AddBook(models.Book{ID: "b2", Title: "El amor en los tiempos del cólera", Author: "Gabriel García Márquez"})
+	s.AddBook(models.Book{ID: "b3", Title: "Marina", Author: "Carlos Ruiz Zafón"})
+	s.AddBook(models.Book{ID: "b4", Title: "Mar adentro", Author: "Ramón Sampedro"})
+
+	got := s.Autocomplete("mar", 10)
+	var texts []string
+	for _, c := range got {
+		texts = append(texts, c.Text)
+	}
+	if !equalIDs(texts, []string{"Gabriel García Márquez", "Mar adentro", "Marina"}) {
+		t.Fatalf("the author of two books should come first: %v", texts)
+	}
+	if got := s.Autocomplete("soledad", 10); len(got) != 1 || got[0].Text != "Cien años de soledad" {
+		t.Fatalf("a later word of the title should match: %+v", got)
+	}
+	if got := s.Autocomplete("garcia marq", 1); len(got) != 1 || len(got[0].BookIDs) != 2 {
+		t.Fatalf("a prefix spanning later words should match: %+v", got)
+	}
+	if got := s.Autocomplete("ruiz", 10); len(got) != 1 || got[0].Text != "Carlos Ruiz Zafón" {
+		t.Fatalf("unexpected completion: %+v", got)
+	}
+
+	s.RemoveBook("b3")
+	if got := s.Autocomplete("zafon", 10); len(got) != 0 {
+		t.Fatalf("removed author still suggested: %+v", got)
+	}
+}
+
+func TestHistoryNewestFirstAndBounded(t *testing.T) {
+	s := NewLibraryService()
+	s.AddUser(models.User{ID: "u1", Name: "Ana"})
+	s.AddBook(models.Book{ID: "b1", Title: "Go", Author: "Gopher"})
+	if err := s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"}); err != nil {
+		t.Fatalf("borrow: %v", err)
+	}
+
+	got := s.History(2)
+	if len(got) != 2 || got[0] != "borrow:u1:b1" || got[1] != "add_book:b1" {
+		t.Fatalf("unexpected history: %v", got)
+	}
+
+	for i := 0; i < historyCapacity; i++ {
+		s.AddUser(models.User{ID: fmt.Sprintf("x%d", i), Name: "N"})
+	}
+	if s.HistorySize() != historyCapacity {
+		t.Fatalf("history should be capped at %d, got %d", historyCapacity, s.HistorySize())
+	}
+}
+
+func TestSearchCacheInvalidatedByWrites(t *testing.T) {
+	s := NewLibraryService()
+	s.AddUser(models.User{ID: "u1", Name: "Ana"})
+	s.AddBook(models.Book{ID: "b1", Title: "Go", Author: "Gopher"})
+
+	if got := s.SearchBooks("go"); len(got) != 1 || !got[0].Available {
+		t.Fatalf("unexpected first search: %+v", got)
+	}
+	if _, cached := s.searches.Peek("go"); !cached {
+		t.Fatalf("search result should be cached")
+	}
+
+	if err := s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"}); err != nil {
+		t.Fatalf("borrow: %v", err)
+	}
+	if got := s.SearchBooks("go"); len(got) != 1 || got[0].Available {
+		t.Fatalf("borrow should invalidate cached availability: %+v", got)
+	}
+
+	s.AddBook(models.Book{ID: "b2", Title: "Go Concurrency", Author: "Gopher"})
+	if got := s.SearchBooks("go"); len(got) != 2 {
+		t.Fatalf("add should invalidate cached results: %+v", got)
+	}
+
+	got := s.SearchBooks("go")
+	got[0].Title = "mutated"
+	if again := s.SearchBooks("go"); again[0].Title == "mutated" {
+		t.Fatalf("callers must not be able to mutate cached results")
+	}
+}
+
+func TestImportBooksRejectsDuplicateISBN(t *testing.T) {
+	s := NewLibraryService()
+	s.AddBook(models.Book{ID: "b1", Title: "C", Author: "K&R", ISBN: "978-0-13-110362-7"})
+
+	result, err := s.ImportBooks([]models.Book{
+		{ID: "b2", Title: "C again", Author: "K&R", ISBN: "9780131103627"},
+		{ID: "b3", Title: "Go", Author: "Donovan", ISBN: "978-0134190440"},
+		{ID: "b4", Title: "Go copy", Author: "Donovan", ISBN: "9780134190440"},
+		{ID: "b5", Title: "No ISBN", Author: "Anon"},
+		{ID: "", Title: "Broken", Author: "Anon"},
+	})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if !equalIDs(result.Imported, []string{"b3", "b5"}) {
+		t.Fatalf("unexpected imported: %v", result.Imported)
+	}
+	reasons := make(map[string]string)
+	for _, r := range result.Rejected {
+		reasons[r.ID] = r.Reason
+	}
+	if reasons["b2"] != "isbn already in catalog" || reasons["b4"] != "duplicate isbn in import" || reasons[""] != "missing fields" {
+		t.Fatalf("unexpected rejections: %+v", result.Rejected)
+	}
+	if len(s.ListBooks()) != 3 {
+		t.Fatalf("expected 3 books in catalog, got %d", len(s.ListBooks()))
+	}
+}
+
+func TestImportBooksRejectsDuplicateID(t *testing.T) {
+	s := NewLibraryService()
+	s.AddUser(models.User{ID: "u1", Name: "Ana"})
+	s.AddBook(models.Book{ID: "b1", Title: "Go", Author: "Gopher"})
+	s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"})
+
+	result, err := s.ImportBooks([]models.Book{
+		{ID: "b1", Title: "Go, 2nd ed.", Author: "Gopher"},
+		{ID: "b9", Title: "Rust", Author: "Ferris", Copies: 3},
+		{ID: "b9", Title: "Rust again", Author: "Ferris", Copies: 1},
+	})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if !equalIDs(result.Imported, []string{"b9"}) {
+		t.Fatalf("unexpected imported: %v", result.Imported)
+	}
+	reasons := make(map[string]string)
+	for _, r := range result.Rejected {
+		reasons[r.ID] = r.Reason
+	}
+	if reasons["b1"] != "id already in catalog" || reasons["b9"] != "duplicate id in import" {
+		t.Fatalf("unexpected rejections: %+v", result.Rejected)
+	}
+	books := s.ListBooks()
+	if books[0].Title != "Go" || books[0].Available {
+		t.Fatalf("loaned book should be left untouched: %+v", books[0])
+	}
+	if books[1].Title != "Rust" || books[1].Copies != 3 || len(s.BookCopies("b9")) != 3 {
+		t.Fatalf("first occurrence should win: %+v", books[1])
+	}
+}
+
+func TestISBNFilterRebuildsWhenSaturated(t *testing.T) {
+	s := NewLibraryService()
+	for i := 0; i <= isbnFilterSize; i++ {
+		s.AddBook(models.Book{ID: fmt.Sprintf("b%06d", i), Title: "T", Author: "A", ISBN: fmt.Sprintf("isbn-%d", i)})
+	}
+	if s.isbns.Saturated() {
+		t.Fatalf("filter should have been rebuilt with more room")
+	}
+	result, _ := s.ImportBooks([]models.Book{{ID: "dup", Title: "T", Author: "A", ISBN: "isbn-0"}})
+	if len(result.Rejected) != 1 {
+		t.Fatalf("duplicate should still be rejected after rebuild: %+v", result)
+	}
+}
+
+func TestIndexStatsShowDegradation(t *testing.T) {
+	balanced := NewLibraryService()
+	unbalanced := NewLibraryServiceWithIndex(IndexUnbalanced)
+	for i := 0; i < 64; i++ {
+		b := models.Book{ID: fmt.Sprintf("b%03d", i), Title: "T", Author: "A"}
+		balanced.AddBook(b)
+		unbalanced.AddBook(b)
+	}
+	if got := balanced.IndexStats()[0]; got.Name != "books" || got.Size != 64 || got.Height > 8 {
+		t.Fatalf("unexpected AVL stats: %+v", got)
+	}
+	if got := unbalanced.IndexStats()[0]; got.Kind != "bst" || got.Height != 64 || got.Leaves != 1 {
+		t.Fatalf("sequential IDs should degrade the unbalanced tree: %+v", got)
+	}
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
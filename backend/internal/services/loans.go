@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"library/internal/ds"
+	"library/internal/models"
+	"library/internal/storage"
+)
+
+// loanPeriod is how long a book may be kept before the loan is overdue.
+const loanPeriod = 14 * 24 * time.Hour
+
+// Renewal policy: a loan can be renewed maxRenewals times, and an overdue loan only
+// within renewalGrace of its due date.
+const (
+	maxRenewals  = 2
+	renewalGrace = 3 * 24 * time.Hour
+)
+
+// Clock tells the service the current time. Tests replace it to control loan dates.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SetClock replaces the clock used to stamp loans and to decide which ones are overdue.
+func (s *LibraryService) SetClock(c Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = c
+}
+
+// Renew pushes the due date of the user's loan of the book forward by loanPeriod. It is
+// refused once the loan was renewed maxRenewals times, when it is overdue past the
+// grace period, or when other patrons are waiting for a copy.
+func (s *LibraryService) Renew(userID, bookID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	loan, err := s.findLoan(userID, bookID)
+	if err != nil {
+		return err
+	}
+	if loan.Renewals >= maxRenewals {
+		return errors.New("renewal limit reached")
+	}
+	if s.clock.Now().After(loan.DueAt.Add(renewalGrace)) {
+		return errors.New("loan overdue past grace period")
+	}
+	if s.waitingHold(bookID) != nil {
+		return errors.New("book has holds from other patrons")
+	}
+	loan.DueAt = loan.DueAt.Add(loanPeriod)
+	loan.Renewals++
+	var batch storage.Batch
+	if err := putRecord(&batch, loanPrefix, loan.Barcode, loan); err != nil {
+		return err
+	}
+	if err := s.persist(&batch); err != nil {
+		return err
+	}
+	s.activeLoans.Put(loan.Barcode, loan)
+	s.history.Push("renew:" + userID + ":" + bookID)
+	return nil
+}
+
+// ListLoans returns the active loans ordered by barcode.
+func (s *LibraryService) ListLoans() []models.Loan {
+	return readIndex(s, func() ds.OrderedMap[string, models.Loan] { return s.activeLoans }, listAll[models.Loan])
+}
+
+// OverdueLoans returns the active loans past their due date, the most overdue first.
+func (s *LibraryService) OverdueLoans() []models.Loan {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := s.clock.Now()
+	out := make([]models.Loan, 0)
+	s.activeLoans.TraverseInOrder(func(_ string, l models.Loan) {
+		if !l.DueAt.IsZero() && now.After(l.DueAt) {
+			out = append(out, l)
+		}
+	})
+	sort.SliceStable(out, func(i, j int) bool { return out[i].DueAt.Before(out[j].DueAt) })
+	return out
+}
+
+// LoansActiveAt returns every loan, active or already returned, that was running at t,
+// ordered by borrow time. Returned loans are looked up in the interval tree of past
+// loans, so the query does not scan the whole loan history.
+func (s *LibraryService) LoansActiveAt(t time.Time) []models.Loan {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]models.Loan, 0)
+	s.returnedLoans.Stabbing(t, func(iv *ds.Interval[time.Time, models.Loan]) bool {
+		out = append(out, iv.Value)
+		return true
+	})
+	s.activeLoans.TraverseInOrder(func(_ string, l models.Loan) {
+		if !l.BorrowedAt.After(t) {
+			out = append(out, l)
+		}
+	})
+	sort.SliceStable(out, func(i, j int) bool { return out[i].BorrowedAt.Before(out[j].BorrowedAt) })
+	return out
+}
+
+func newLoanLog() *ds.IntervalTree[time.Time, models.Loan] {
+	return ds.NewIntervalTree[time.Time, models.Loan](func(a, b time.Time) int { return a.Compare(b) })
+}
+
+// logReturnedLoan adds a returned loan to the interval tree of past loans as the range
+// [BorrowedAt, ReturnedAt).
+func (s *LibraryService) logReturnedLoan(l models.Loan) {
+	if l.ReturnedAt != nil {
+		s.returnedLoans.Insert(l.BorrowedAt, *l.ReturnedAt, l)
+	}
+}
+
+// returnedLoanID identifies a returned loan in the store: a copy is only lent once at a
+// time, so the copy and the borrow time are unique.
+func returnedLoanID(l models.Loan) string {
+	return l.Barcode + "/" + l.BorrowedAt.UTC().Format(time.RFC3339Nano)
+}
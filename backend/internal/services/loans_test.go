@@ -0,0 +1,154 @@
+package services
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"library/internal/models"
+	"library/internal/storage"
+)
+
+// fakeClock is a Clock that only moves when the test advances it.
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newClockedService(t *testing.T) (*LibraryService, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)}
+	s := NewLibraryService()
+	s.SetClock(clock)
+	s.AddUser(models.User{ID: "u1", Name: "Ana"})
+	s.AddUser(models.User{ID: "u2", Name: "Luis"})
+	s.AddBook(models.Book{ID: "b1", Title: "Go", Author: "Gopher"})
+	s.AddBook(models.Book{ID: "b2", Title: "Rust", Author: "Ferris"})
+	return s, clock
+}
+
+func TestLoanDueDatesAndOverdue(t *testing.T) {
+	s, clock := newClockedService(t)
+	start := clock.now
+	if err := s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"}); err != nil {
+		t.Fatalf("borrow: %v", err)
+	}
+	clock.advance(24 * time.Hour)
+	if err := s.Borrow(models.LoanRequest{UserID: "u2", BookID: "b2"}); err != nil {
+		t.Fatalf("borrow: %v", err)
+	}
+
+	loans := s.ListLoans()
+	if len(loans) != 2 || !loans[0].BorrowedAt.Equal(start) || !loans[0].DueAt.Equal(start.Add(loanPeriod)) || loans[0].ReturnedAt != nil {
+		t.Fatalf("unexpected loans: %+v", loans)
+	}
+	if got := s.OverdueLoans(); len(got) != 0 {
+		t.Fatalf("nothing should be overdue yet: %+v", got)
+	}
+
+	clock.advance(loanPeriod + time.Hour)
+	overdue := s.OverdueLoans()
+	if len(overdue) != 2 || overdue[0].BookID != "b1" || overdue[1].BookID != "b2" {
+		t.Fatalf("both loans should be overdue, oldest first: %+v", overdue)
+	}
+	if err := s.Return(models.LoanRequest{UserID: "u1", BookID: "b1"}); err != nil {
+		t.Fatalf("return: %v", err)
+	}
+	if overdue := s.OverdueLoans(); len(overdue) != 1 || overdue[0].BookID != "b2" {
+		t.Fatalf("returned loan should leave the overdue list: %+v", overdue)
+	}
+}
+
+func TestLoansActiveAt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "library.db")
+	store, err := storage.Open(path, storage.Options{})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	s, clock := newClockedService(t)
+	if err := s.AttachStore(store); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	day1 := clock.now
+	s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"})
+	clock.advance(48 * time.Hour)
+	s.Return(models.LoanRequest{UserID: "u1", BookID: "b1"})
+	s.Borrow(models.LoanRequest{UserID: "u2", BookID: "b2"})
+
+	check := func(s *LibraryService, at time.Time, want ...string) {
+		t.Helper()
+		got := make([]string, 0)
+		for _, l := range s.LoansActiveAt(at) {
+			got = append(got, l.UserID+":"+l.BookID)
+		}
+		if !equalIDs(got, want) {
+			t.Fatalf("loans active at %v: %v, want %v", at, got, want)
+		}
+	}
+	check(s, day1.Add(-time.Hour))
+	check(s, day1.Add(24*time.Hour), "u1:b1")
+	check(s, clock.now, "u2:b2")
+
+	// The returned loan is persisted and reloaded with its timestamps.
+	store.Close()
+	store, err = storage.Open(path, storage.Options{})
+	if err != nil {
+		t.Fatalf("reopen store: %v", err)
+	}
+	defer store.Close()
+	reloaded := NewLibraryService()
+	if err := reloaded.AttachStore(store); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	check(reloaded, day1.Add(24*time.Hour), "u1:b1")
+
+	var buf bytes.Buffer
+	if err := reloaded.Snapshot(&buf); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	restored := NewLibraryService()
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	check(restored, day1.Add(24*time.Hour), "u1:b1")
+	check(restored, clock.now, "u2:b2")
+}
+
+func TestRenewPolicy(t *testing.T) {
+	s, clock := newClockedService(t)
+	if err := s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"}); err != nil {
+		t.Fatalf("borrow: %v", err)
+	}
+	due := s.ListLoans()[0].DueAt
+
+	if err := s.Renew("u2", "b1"); err == nil {
+		t.Fatalf("only the borrower may renew")
+	}
+	if err := s.Renew("u1", "b2"); err == nil {
+		t.Fatalf("renewing a book that is not loaned should fail")
+	}
+
+	// Overdue, but still within the grace period.
+	clock.now = due.Add(renewalGrace - time.Hour)
+	if err := s.Renew("u1", "b1"); err != nil {
+		t.Fatalf("renew within grace: %v", err)
+	}
+	loan := s.ListLoans()[0]
+	if !loan.DueAt.Equal(due.Add(loanPeriod)) || loan.Renewals != 1 {
+		t.Fatalf("due date not pushed forward: %+v", loan)
+	}
+	if err := s.Renew("u1", "b1"); err != nil {
+		t.Fatalf("second renewal: %v", err)
+	}
+	if err := s.Renew("u1", "b1"); err == nil || err.Error() != "renewal limit reached" {
+		t.Fatalf("third renewal should hit the limit, got %v", err)
+	}
+
+	s.Borrow(models.LoanRequest{UserID: "u2", BookID: "b2"})
+	clock.now = s.ListLoans()[1].DueAt.Add(renewalGrace + time.Hour)
+	if err := s.Renew("u2", "b2"); err == nil || err.Error() != "loan overdue past grace period" {
+		t.Fatalf("renewal past grace should fail, got %v", err)
+	}
+}
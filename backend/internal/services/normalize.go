@@ -0,0 +1,74 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// foldText puts text in the form every comparison in the catalog uses: decomposed as
+// in NFD with the combining marks dropped, and case-folded. "García Márquez" and
+// "GARCIA MARQUEZ" both become "garcia marquez", and "niño" becomes "nino".
+//
+// The standard library has no normalization tables, so precomposed letters are
+// decomposed with the table below, which covers Latin-1 and Latin Extended-A (every
+// accented letter used in Spanish and the other Western European languages). Marks
+// that arrive already decomposed are dropped as nonspacing marks.
+func foldText(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range text {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if base, ok := decomposed[r]; ok {
+			r = base
+		}
+		if full, ok := fullFolds[r]; ok {
+			b.WriteString(full)
+			continue
+		}
+		b.WriteRune(unicode.ToLower(unicode.ToUpper(r)))
+	}
+	return b.String()
+}
+
+// fullFolds holds the case foldings that expand to more than one letter.
+var fullFolds = map[rune]string{
+	'ß': "ss", 'ẞ': "ss",
+	'ﬀ': "ff", 'ﬁ': "fi", 'ﬂ': "fl", 'ﬃ': "ffi", 'ﬄ': "ffl", 'ﬅ': "st", 'ﬆ': "st",
+}
+
+// decomposed maps each precomposed letter to the base letter of its NFD form.
+var decomposed = func() map[rune]rune {
+	groups := []struct {
+		letters string
+		base    rune
+	}{
+		{"ÀÁÂÃÄÅĀĂĄ", 'A'}, {"àáâãäåāăą", 'a'},
+		{"ÇĆĈĊČ", 'C'}, {"çćĉċč", 'c'},
+		{"Ď", 'D'}, {"ď", 'd'},
+		{"ÈÉÊËĒĔĖĘĚ", 'E'}, {"èéêëēĕėęě", 'e'},
+		{"ĜĞĠĢ", 'G'}, {"ĝğġģ", 'g'},
+		{"Ĥ", 'H'}, {"ĥ", 'h'},
+		{"ÌÍÎÏĨĪĬĮİ", 'I'}, {"ìíîïĩīĭį", 'i'},
+		{"Ĵ", 'J'}, {"ĵ", 'j'},
+		{"Ķ", 'K'}, {"ķ", 'k'},
+		{"ĹĻĽ", 'L'}, {"ĺļľ", 'l'},
+		{"ÑŃŅŇ", 'N'}, {"ñńņň", 'n'},
+		{"ÒÓÔÕÖŌŎŐ", 'O'}, {"òóôõöōŏő", 'o'},
+		{"ŔŖŘ", 'R'}, {"ŕŗř", 'r'},
+		{"ŚŜŞŠ", 'S'}, {"śŝşš", 's'},
+		{"ŢŤ", 'T'}, {"ţť", 't'},
+		{"ÙÚÛÜŨŪŬŮŰŲ", 'U'}, {"ùúûüũūŭůűų", 'u'},
+		{"Ŵ", 'W'}, {"ŵ", 'w'},
+		{"ÝŶŸ", 'Y'}, {"ýÿŷ", 'y'},
+		{"ŹŻŽ", 'Z'}, {"źżž", 'z'},
+	}
+	out := make(map[rune]rune)
+	for _, g := range groups {
+		for _, r := range g.letters {
+			out[r] = g.base
+		}
+	}
+	return out
+}()
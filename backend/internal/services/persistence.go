@@ -0,0 +1,156 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"library/internal/ds"
+	"library/internal/models"
+	"library/internal/storage"
+)
+
+// Key prefixes of each record type in the attached store. Keys sort by prefix and then
+// by ID, so each record type is one contiguous range of the B+tree.
+const (
+	bookPrefix = "book/"
+	// copyPrefix holds the copies of every book, keyed by barcode.
+	copyPrefix = "copy/"
+	userPrefix = "user/"
+	// loanPrefix holds the active loans, keyed by the barcode of the loaned copy.
+	loanPrefix = "loan/"
+	// returnedPrefix holds finished loans, keyed by copy and borrow time.
+	returnedPrefix = "returned/"
+	// finePrefix holds the fines ledger, keyed like the in-memory ledger index.
+	finePrefix = "fine/"
+	// holdPrefix holds the hold queues, keyed by book ID and hold ID.
+	holdPrefix = "hold/"
+)
+
+// MaxIDLength bounds book IDs, user IDs and copy barcodes, in bytes, so that every key
+// derived from them fits in storage.MaxKeySize. The longest is a returned loan: the
+// prefix, a generated barcode (the book ID plus 9 bytes), "/" and a 30-byte borrow time.
+const MaxIDLength = 128
+
+// AttachStore makes the service durable: the books, copies, users and loans in store are
+// loaded into the in-memory indexes, and from then on every change is written to store
+// before it is applied in memory, one atomic batch per operation. The store is a
+// write-through copy: every read is served from memory, so the whole state must still
+// fit in RAM. Everything is read before any of it is applied, so a failed load leaves
+// the service as it was.
+func (s *LibraryService) AttachStore(store *storage.BTree) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	books, loaded, err := loadIndexRecords(store, bookPrefix, s.kind, s.books, func(b models.Book) string { return b.ID })
+	if err != nil {
+		return err
+	}
+	copies, _, err := loadIndexRecords(store, copyPrefix, s.kind, s.copies, func(c models.Copy) string { return c.Barcode })
+	if err != nil {
+		return err
+	}
+	users, _, err := loadIndexRecords(store, userPrefix, s.kind, s.users, func(u models.User) string { return u.ID })
+	if err != nil {
+		return err
+	}
+	loans, _, err := loadIndexRecords(store, loanPrefix, s.kind, s.activeLoans, func(l models.Loan) string { return l.Barcode })
+	if err != nil {
+		return err
+	}
+	var returned []models.Loan
+	if err := loadRecords(store, returnedPrefix, func(l models.Loan) { returned = append(returned, l) }); err != nil {
+		return err
+	}
+	ledger, _, err := loadIndexRecords(store, finePrefix, s.kind, s.ledger, ledgerKey)
+	if err != nil {
+		return err
+	}
+	var holds []models.Hold
+	if err := loadRecords(store, holdPrefix, func(h models.Hold) { holds = append(holds, h) }); err != nil {
+		return err
+	}
+	s.books, s.copies, s.users, s.activeLoans, s.ledger = books, copies, users, loans, ledger
+	for _, l := range returned {
+		s.logReturnedLoan(l)
+	}
+	for _, h := range holds {
+		s.enqueueHold(h)
+	}
+	s.restoreBookCopies()
+	s.restoreLedgerSeq()
+	s.restoreHoldSeq()
+	for _, b := range loaded {
+		s.indexBook(b)
+	}
+	s.invalidateSearches()
+	s.store = store
+	return nil
+}
+
+// loadIndexRecords returns a new index holding the entries of index and the records
+// under prefix; index itself is left untouched. The store returns the records sorted by
+// key, which is also ID order, so when index is empty an AVL index is bulk-built.
+func loadIndexRecords[V any](store *storage.BTree, prefix string, kind IndexKind, index ds.OrderedMap[string, V], id func(V) string) (ds.OrderedMap[string, V], []V, error) {
+	var ids []string
+	var values []V
+	err := loadRecords(store, prefix, func(v V) {
+		ids = append(ids, id(v))
+		values = append(values, v)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	loaded := newIndex[V](kind)
+	index.TraverseInOrder(func(key string, v V) { loaded.Put(key, v) })
+	loaded, err = loadIndex(kind, loaded, ids, values)
+	return loaded, values, err
+}
+
+// persist writes the batch to the attached store, if any. Callers build the batch
+// before touching memory so a failed write leaves the service unchanged.
+func (s *LibraryService) persist(b *storage.Batch) error {
+	if s.store == nil || b.Len() == 0 {
+		return nil
+	}
+	return s.store.Write(b)
+}
+
+// putRecord adds v to the batch as JSON. Records whose key or value exceed the store's
+// entry limits are refused even when no store is attached, so the service accepts the
+// same data either way.
+func putRecord(b *storage.Batch, prefix, id string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	key := prefix + id
+	if len(key) > storage.MaxKeySize || len(raw) > storage.MaxValueSize {
+		return fmt.Errorf("%w: %s record of %d bytes with a %d-byte key (limits %d and %d)",
+			storage.ErrEntryTooLarge, strings.TrimSuffix(prefix, "/"), len(raw), len(key), storage.MaxValueSize, storage.MaxKeySize)
+	}
+	b.Put([]byte(key), raw)
+	return nil
+}
+
+func deleteRecord(b *storage.Batch, prefix, id string) {
+	b.Delete([]byte(prefix + id))
+}
+
+func loadRecords[V any](store *storage.BTree, prefix string, fn func(V)) error {
+	var decodeErr error
+	err := store.Scan([]byte(prefix), func(key, value []byte) bool {
+		if !strings.HasPrefix(string(key), prefix) {
+			return false
+		}
+		var v V
+		if decodeErr = json.Unmarshal(value, &v); decodeErr != nil {
+			return false
+		}
+		fn(v)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return decodeErr
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"library/internal/ds"
+	"library/internal/models"
+	"library/internal/storage"
+)
+
+func TestAttachedStoreSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "library.db")
+	open := func() (*LibraryService, *storage.BTree) {
+		t.Helper()
+		store, err := storage.Open(path, storage.Options{})
+		if err != nil {
+			t.Fatalf("open store: %v", err)
+		}
+		s := NewLibraryService()
+		if err := s.AttachStore(store); err != nil {
+			t.Fatalf("attach: %v", err)
+		}
+		return s, store
+	}
+
+	s, store := open()
+	s.AddUser(models.User{ID: "u1", Name: "Ana"})
+	s.AddUser(models.User{ID: "u2", Name: "Luis"})
+	s.AddBook(models.Book{ID: "b1", Title: "Go", Author: "Gopher"})
+	s.AddBook(models.Book{ID: "b2", Title: "Rust", Author: "Ferris"})
+	if err := s.Borrow(models.LoanRequest{UserID: "u1", BookID: "b1"}); err != nil {
+		t.Fatalf("borrow: %v", err)
+	}
+	if err := s.RemoveBook("b2"); err != nil {
+		t.Fatalf("remove book: %v", err)
+	}
+	if err := s.RemoveUser("u2"); err != nil {
+		t.Fatalf("remove user: %v", err)
+	}
+	store.Close()
+
+	s, store = open()
+	defer store.Close()
+	books := s.ListBooks()
+	if len(books) != 1 || books[0].ID != "b1" || books[0].Available {
+		t.Fatalf("unexpected books after restart: %+v", books)
+	}
+	if users := s.ListUsers(); len(users) != 1 || users[0].ID != "u1" {
+		t.Fatalf("unexpected users after restart: %+v", users)
+	}
+	if got := s.Autocomplete("go", 5); len(got) != 2 {
+		t.Fatalf("autocomplete index not rebuilt: %+v", got)
+	}
+	if err := s.Return(models.LoanRequest{UserID: "u1", BookID: "b1"}); err != nil {
+		t.Fatalf("loan should survive restart: %v", err)
+	}
+}
+
+func TestRecordsOverStoreLimitsAreRefused(t *testing.T) {
+	s := NewLibraryService()
+	_, err := s.AddBook(models.Book{ID: "b1", Title: strings.Repeat("x", storage.MaxValueSize), Author: "A"})
+	if !errors.Is(err, storage.ErrEntryTooLarge) {
+		t.Fatalf("oversized record should be refused without a store too, got %v", err)
+	}
+	if len(s.ListBooks()) != 0 {
+		t.Fatalf("refused book should not be added")
+	}
+}
+
+func TestLongestIDsFitEveryRecord(t *testing.T) {
+	s, clock := newClockedService(t)
+	clock.advance(123456789) // a borrow time with every fractional digit
+	bookID, userID := strings.Repeat("b", MaxIDLength), strings.Repeat("u", MaxIDLength)
+	if _, err := s.AddBook(models.Book{ID: bookID + "b", Title: "T", Author: "A"}); err == nil {
+		t.Fatalf("book id over MaxIDLength should be refused")
+	}
+	if err := s.AddUser(models.User{ID: userID + "u", Name: "N"}); err == nil {
+		t.Fatalf("user id over MaxIDLength should be refused")
+	}
+	if _, err := s.AddCopy("b1", strings.Repeat("c", MaxIDLength+1)); err == nil {
+		t.Fatalf("barcode over MaxIDLength should be refused")
+	}
+
+	if _, err := s.AddBook(models.Book{ID: bookID, Title: "T", Author: "A", Copies: MaxCopies}); err != nil {
+		t.Fatalf("add book: %v", err)
+	}
+	if err := s.AddUser(models.User{ID: userID, Name: "N"}); err != nil {
+		t.Fatalf("add user: %v", err)
+	}
+	for i := 1; i < MaxCopies; i++ {
+		s.RemoveCopy(copyBarcode(bookID, i))
+	}
+	if _, err := s.AddCopy(bookID, strings.Repeat("c", MaxIDLength)); err != nil {
+		t.Fatalf("add copy: %v", err)
+	}
+	// Every record derived from the IDs: loans, a hold, returned loans and fines.
+	if err := s.Borrow(models.LoanRequest{UserID: "u1", BookID: bookID}); err != nil {
+		t.Fatalf("borrow: %v", err)
+	}
+	if err := s.Borrow(models.LoanRequest{UserID: userID, BookID: bookID}); err != nil {
+		t.Fatalf("borrow: %v", err)
+	}
+	if _, err := s.PlaceHold("u2", bookID); err != nil {
+		t.Fatalf("hold: %v", err)
+	}
+	clock.advance(loanPeriod + 24*time.Hour)
+	for _, user := range []string{userID, "u1"} {
+		if err := s.Return(models.LoanRequest{UserID: user, BookID: bookID}); err != nil {
+			t.Fatalf("return: %v", err)
+		}
+	}
+	if account, err := s.FineAccount(userID); err != nil || len(account.Entries) != 1 {
+		t.Fatalf("late return should be fined: %+v, %v", account, err)
+	}
+	if got := s.LoansActiveAt(clock.now.Add(-loanPeriod)); len(got) != 2 {
+		t.Fatalf("returned loans should be logged: %+v", got)
+	}
+}
+
+func TestFailedAttachLeavesServiceUnchanged(t *testing.T) {
+	store, err := storage.Open(filepath.Join(t.TempDir(), "library.db"), storage.Options{})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer store.Close()
+	borrowed := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	returned := borrowed.Add(24 * time.Hour)
+	var batch storage.Batch
+	putRecord(&batch, bookPrefix, "b9", models.Book{ID: "b9", Title: "Stored", Author: "A"})
+	putRecord(&batch, returnedPrefix, "c9/x", models.Loan{UserID: "u1", BookID: "b9", Barcode: "c9", BorrowedAt: borrowed, ReturnedAt: &returned})
+	batch.Put([]byte(holdPrefix+"b9/1"), []byte("{"))
+	if err := store.Write(&batch); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	s, _ := newClockedService(t)
+	if err := s.AttachStore(store); err == nil {
+		t.Fatalf("a corrupt hold record should fail the load")
+	}
+	if got := bookIDs(s.ListBooks()); !equalIDs(got, []string{"b1", "b2"}) {
+		t.Fatalf("books changed by a failed load: %v", got)
+	}
+	if got := s.LoansActiveAt(borrowed.Add(time.Hour)); len(got) != 0 {
+		t.Fatalf("returned loans loaded by a failed load: %+v", got)
+	}
+	if _, err := s.AddBook(models.Book{ID: "b3", Title: "C", Author: "K&R"}); err != nil {
+		t.Fatalf("service should stay usable without the store: %v", err)
+	}
+}
+
+func TestRefusedImportLeavesNoCopies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "library.db")
+	store, err := storage.Open(path, storage.Options{})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	s := NewLibraryService()
+	if err := s.AttachStore(store); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	result, err := s.ImportBooks([]models.Book{
+		{ID: "b1", Title: strings.Repeat("x", storage.MaxValueSize), Author: "A"},
+		{ID: "b2", Title: "Go", Author: "Gopher"},
+	})
+	if err != nil || len(result.Rejected) != 1 || !equalIDs(result.Imported, []string{"b2"}) {
+		t.Fatalf("unexpected import: %+v, %v", result, err)
+	}
+	store.Close()
+
+	store, err = storage.Open(path, storage.Options{})
+	if err != nil {
+		t.Fatalf("reopen store: %v", err)
+	}
+	defer store.Close()
+	s = NewLibraryService()
+	if err := s.AttachStore(store); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	if _, err := s.AddBook(models.Book{ID: "b1", Title: "Rust", Author: "Ferris"}); err != nil {
+		t.Fatalf("the refused book should leave no copies behind: %v", err)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	for _, kind := range []IndexKind{IndexAVL, IndexSkipList, IndexPersistent} {
+		src := NewLibraryServiceWithIndex(kind)
+		src.AddUser(models.User{ID: "u1", Name: "Ana"})
+		src.AddBook(models.Book{ID: "b1", Title: "Go", Author: "Gopher", ISBN: "111"})
+		src.AddBook(models.Book{ID: "b2", Title: "Rust", Author: "Ferris"})
+		if err := src.Borrow(models.LoanRequest{UserID: "u1", BookID: "b2"}); err != nil {
+			t.Fatalf("%s: borrow: %v", kind, err)
+		}
+		var buf bytes.Buffer
+		if err := src.Snapshot(&buf); err != nil {
+			t.Fatalf("%s: snapshot: %v", kind, err)
+		}
+
+		dst := NewLibraryServiceWithIndex(kind)
+		dst.AddBook(models.Book{ID: "stale", Title: "Old", Author: "Nobody"})
+		if err := dst.Restore(&buf); err != nil {
+			t.Fatalf("%s: restore: %v", kind, err)
+		}
+		books := dst.ListBooks()
+		if len(books) != 2 || books[0].ID != "b1" || books[1].Available {
+			t.Fatalf("%s: unexpected books: %+v", kind, books)
+		}
+		if got := dst.Autocomplete("old", 5); len(got) != 0 {
+			t.Fatalf("%s: stale completions survived restore: %+v", kind, got)
+		}
+		if !dst.hasISBN("111") {
+			t.Fatalf("%s: ISBN filter not rebuilt", kind)
+		}
+		if err := dst.Return(models.LoanRequest{UserID: "u1", BookID: "b2"}); err != nil {
+			t.Fatalf("%s: restored loan: %v", kind, err)
+		}
+	}
+
+	if err := NewLibraryService().Restore(strings.NewReader("garbage")); err == nil {
+		t.Fatalf("restoring garbage should fail")
+	}
+}
+
+// writerFunc adapts a function to io.Writer.
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+func TestSnapshotWritesOutsideTheLock(t *testing.T) {
+	for _, kind := range []IndexKind{IndexAVL, IndexPersistent} {
+		s := NewLibraryServiceWithIndex(kind)
+		s.AddBook(models.Book{ID: "b1", Title: "Go", Author: "Gopher"})
+		// A client that reads slowly: writers must still get through meanwhile.
+		slow := writerFunc(func(p []byte) (int, error) {
+			done := make(chan error, 1)
+			go func() { done <- s.AddUser(models.User{ID: "u1", Name: "Ana"}) }()
+			select {
+			case err := <-done:
+				return len(p), err
+			case <-time.After(time.Second):
+				return 0, errors.New("writer blocked by the snapshot")
+			}
+		})
+		if err := s.Snapshot(slow); err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+	}
+}
+
+func TestRestoreRefusesOtherSnapshotVersions(t *testing.T) {
+	future := binary.AppendUvarint(snapshotMagic[:], snapshotVersion+1)
+	err := NewLibraryService().Restore(bytes.NewReader(future))
+	if err == nil || !strings.Contains(err.Error(), "unsupported snapshot version") {
+		t.Fatalf("newer snapshot versions should be refused clearly, got %v", err)
+	}
+
+	// A bare BST stream, as the format was before the header.
+	var buf bytes.Buffer
+	encodeIndex[models.Book](&buf, ds.NewBST[string, models.Book](strings.Compare))
+	if err := NewLibraryService().Restore(&buf); err == nil {
+		t.Fatalf("a snapshot without the header should be refused")
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"library/internal/ds"
+	"library/internal/models"
+)
+
+// A snapshot starts with snapshotMagic and the format version as a uvarint. Version 1
+// holds the seven streams written by Snapshot; a change to the streams must bump it.
+var snapshotMagic = [4]byte{'L', 'I', 'B', 'S'}
+
+const snapshotVersion = 1
+
+// Snapshot writes the books, users, active loans, returned loans, fines ledger, hold
+// queues and copies to w as seven binary BST streams, in that order, after the format
+// header. Keys are IDs and values are JSON records. The snapshot is encoded into memory
+// and only written to w once mu is released, so a slow reader never holds up writers;
+// persistent indexes are pinned to their current version under mu and encoded after.
+func (s *LibraryService) Snapshot(w io.Writer) error {
+	s.mu.RLock()
+	returned := ds.NewBST[string, models.Loan](strings.Compare)
+	s.returnedLoans.Ascend(func(iv *ds.Interval[time.Time, models.Loan]) bool {
+		returned.Put(returnedLoanID(iv.Value), iv.Value)
+		return true
+	})
+	holds := ds.NewBST[string, models.Hold](strings.Compare)
+	for _, queue := range s.holds {
+		queue.ForEach(func(h models.Hold) { holds.Put(holdKey(h), h) })
+	}
+	streams := []func(io.Writer) error{
+		snapshotStream(s.books),
+		snapshotStream(s.users),
+		snapshotStream(s.activeLoans),
+		snapshotStream[models.Loan](returned),
+		snapshotStream(s.ledger),
+		snapshotStream[models.Hold](holds),
+		snapshotStream(s.copies),
+	}
+	var buf bytes.Buffer
+	buf.Write(binary.AppendUvarint(snapshotMagic[:], snapshotVersion))
+	encode := func() error {
+		for _, stream := range streams {
+			if err := stream(&buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var err error
+	if s.kind == IndexPersistent {
+		s.mu.RUnlock()
+		err = encode()
+	} else {
+		err = encode()
+		s.mu.RUnlock()
+	}
+	if err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// snapshotStream returns the encoder of one snapshot stream. A persistent index is
+// pinned to its current version, which later writes leave untouched.
+func snapshotStream[V any](index ds.OrderedMap[string, V]) func(io.Writer) error {
+	if versioned, ok := index.(*ds.VersionedMap[string, V]); ok {
+		version := versioned.Snapshot()
+		return func(w io.Writer) error { return encodeIndex[V](w, version) }
+	}
+	return func(w io.Writer) error { return encodeIndex(w, index) }
+}
+
+// Restore replaces the service state with a snapshot written by Snapshot and rebuilds
+// the derived indexes. Snapshots of another format version are refused, and so is a
+// service with an attached store, since the store would no longer match memory.
+func (s *LibraryService) Restore(r io.Reader) error {
+	br := bufio.NewReader(r)
+	if err := readSnapshotHeader(br); err != nil {
+		return err
+	}
+	books, err := decodeIndex[models.Book](br)
+	if err != nil {
+		return err
+	}
+	users, err := decodeIndex[models.User](br)
+	if err != nil {
+		return err
+	}
+	loans, err := decodeIndex[models.Loan](br)
+	if err != nil {
+		return err
+	}
+	returned, err := decodeIndex[models.Loan](br)
+	if err != nil {
+		return err
+	}
+	ledger, err := decodeIndex[models.LedgerEntry](br)
+	if err != nil {
+		return err
+	}
+	holds, err := decodeIndex[models.Hold](br)
+	if err != nil {
+		return err
+	}
+	copies, err := decodeIndex[models.Copy](br)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.store != nil {
+		return errors.New("cannot restore a service with an attached store")
+	}
+	s.books = restoredIndex(s.kind, books)
+	s.copies = restoredIndex(s.kind, copies)
+	s.restoreBookCopies()
+	s.users = restoredIndex(s.kind, users)
+	s.activeLoans = restoredIndex(s.kind, loans)
+	s.returnedLoans = newLoanLog()
+	returned.TraverseInOrder(func(_ string, l models.Loan) { s.logReturnedLoan(l) })
+	s.ledger = restoredIndex(s.kind, ledger)
+	s.restoreLedgerSeq()
+	s.holds = make(map[string]*ds.List[models.Hold])
+	holds.TraverseInOrder(func(_ string, h models.Hold) { s.enqueueHold(h) })
+	s.restoreHoldSeq()
+	s.completions = ds.NewTrie[*completion]()
+	s.completionWords = ds.NewTrie[map[string]struct{}]()
+	s.text = newTextIndex()
+	s.authors = newTextIndex()
+	s.isbns = ds.NewBloomFilter[string](isbnFilterSize, isbnFalsePositiveRate)
+	s.books.TraverseInOrder(func(_ string, b models.Book) { s.indexBook(b) })
+	s.invalidateSearches()
+	s.history.Push("restore")
+	return nil
+}
+
+// encodeIndex serializes any index through ds.BST; other kinds are first copied into a
+// tree bulk-built from their sorted entries.
+func encodeIndex[V any](w io.Writer, index indexReader[V]) error {
+	tree, ok := index.(*ds.BST[string, V])
+	if !ok {
+		ids := make([]string, 0, index.Size())
+		values := make([]V, 0, index.Size())
+		index.TraverseInOrder(func(id string, v V) {
+			ids = append(ids, id)
+			values = append(values, v)
+		})
+		var err error
+		if tree, err = ds.BuildFromSorted(strings.Compare, ids, values); err != nil {
+			return err
+		}
+	}
+	return tree.Encode(w, ds.StringCodec, jsonCodec[V]())
+}
+
+func decodeIndex[V any](r *bufio.Reader) (*ds.BST[string, V], error) {
+	return ds.DecodeBST(r, strings.Compare, ds.StringCodec, jsonCodec[V]())
+}
+
+// readSnapshotHeader checks the format header.
+func readSnapshotHeader(r *bufio.Reader) error {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil || magic != snapshotMagic {
+		return errors.New("not a library snapshot")
+	}
+	version, err := binary.ReadUvarint(r)
+	if err != nil {
+		return errors.New("truncated snapshot header")
+	}
+	if version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d (this build reads version %d)", version, snapshotVersion)
+	}
+	return nil
+}
+
+// restoredIndex uses the decoded tree as is for AVL services and copies it into the
+// configured structure otherwise.
+func restoredIndex[V any](kind IndexKind, tree *ds.BST[string, V]) ds.OrderedMap[string, V] {
+	if kind == IndexAVL {
+		return tree
+	}
+	index := newIndex[V](kind)
+	tree.TraverseInOrder(func(id string, v V) { index.Put(id, v) })
+	return index
+}
+
+func jsonCodec[V any]() ds.Codec[V] {
+	return ds.Codec[V]{
+		Encode: func(v V) ([]byte, error) { return json.Marshal(v) },
+		Decode: func(data []byte) (V, error) {
+			var v V
+			err := json.Unmarshal(data, &v)
+			return v, err
+		},
+	}
+}
@@ -0,0 +1,547 @@
+// Package storage provides durable, file-backed structures for the library state.
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+const (
+	// MaxKeySize and MaxValueSize bound a single entry so that splitting any overfull
+	// node always yields two halves that fit in a page.
+	MaxKeySize   = 256
+	MaxValueSize = 1024
+
+	// DefaultPoolSize is the number of pages the buffer pool keeps when Options leaves
+	// it unset.
+	DefaultPoolSize = 256
+
+	metaPage pageID = 0
+
+	kindLeaf     = 1
+	kindInternal = 2
+
+	// nodeHeader is kind (1 byte), entry count (2 bytes) and next leaf (4 bytes).
+	nodeHeader  = 7
+	nodePayload = checksumOffset - nodeHeader
+)
+
+var metaMagic = [8]byte{'L', 'I', 'B', 'B', 'T', 'R', 'E', 'E'}
+
+var (
+	// ErrEntryTooLarge is returned when a key or value exceeds its size limit.
+	ErrEntryTooLarge = errors.New("storage: key or value too large")
+	// ErrEmptyKey is returned when putting an empty key.
+	ErrEmptyKey = errors.New("storage: empty key")
+	// ErrClosed is returned by operations on a closed tree.
+	ErrClosed = errors.New("storage: tree closed")
+)
+
+// Options tunes a BTree.
+type Options struct {
+	// PoolSize is how many clean pages the buffer pool caches. Zero means
+	// DefaultPoolSize.
+	PoolSize int
+}
+
+// BTree is a B+tree stored in a single file of fixed-size pages. Keys and values are
+// byte strings ordered with bytes.Compare. Values live only in leaves, and leaves are
+// linked left to right so Scan walks them without going back up the tree.
+//
+// Every Write is atomic and durable once it returns (see pager). Deletes remove entries
+// from their leaf without merging underfull nodes, and freed space is not reused; that
+// keeps the write path short at the cost of some file growth under heavy churn.
+//
+// A BTree is safe for concurrent use.
+type BTree struct {
+	mu    sync.Mutex
+	pager *pager
+	root  pageID
+	count uint64
+	// failed is set when a commit fails after the data file may have been touched; the
+	// in-memory state can no longer be trusted and the tree must be reopened.
+	failed error
+}
+
+type node struct {
+	leaf     bool
+	keys     [][]byte
+	values   [][]byte
+	children []pageID
+	next     pageID
+}
+
+// Open opens the tree stored at path, creating it if the file does not exist, and
+// replays any journal left by an interrupted commit.
+func Open(path string, opts Options) (*BTree, error) {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = DefaultPoolSize
+	}
+	p, err := openPager(path, opts.PoolSize)
+	if err != nil {
+		return nil, err
+	}
+	t := &BTree{pager: p}
+	if p.pageCount == 0 {
+		err = t.initialize()
+	} else {
+		err = t.readMeta()
+	}
+	if err != nil {
+		p.file.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *BTree) initialize() error {
+	t.pager.allocate()
+	root := t.pager.allocate()
+	encodeNode(root, &node{leaf: true})
+	t.root = root.id
+	if err := t.writeMeta(); err != nil {
+		return err
+	}
+	return t.pager.commit()
+}
+
+func (t *BTree) readMeta() error {
+	pg, err := t.pager.get(metaPage)
+	if err != nil {
+		return err
+	}
+	if [8]byte(pg.data[:8]) != metaMagic {
+		return errors.New("storage: not a btree file")
+	}
+	t.root = pageID(binary.LittleEndian.Uint32(pg.data[8:]))
+	t.count = binary.LittleEndian.Uint64(pg.data[12:])
+	return nil
+}
+
+func (t *BTree) writeMeta() error {
+	pg, err := t.pager.writable(metaPage)
+	if err != nil {
+		return err
+	}
+	copy(pg.data[:8], metaMagic[:])
+	binary.LittleEndian.PutUint32(pg.data[8:], uint32(t.root))
+	binary.LittleEndian.PutUint64(pg.data[12:], t.count)
+	return nil
+}
+
+// Len returns the number of keys in the tree.
+func (t *BTree) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return int(t.count)
+}
+
+// Get returns the value stored under key. The slice is the caller's to keep.
+func (t *BTree) Get(key []byte) ([]byte, bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.usable(); err != nil {
+		return nil, false, err
+	}
+	n, _, err := t.findLeaf(key)
+	if err != nil {
+		return nil, false, err
+	}
+	i, found := n.search(key)
+	if !found {
+		return nil, false, nil
+	}
+	return n.values[i], true, nil
+}
+
+// Put stores value under key in its own atomic write.
+func (t *BTree) Put(key, value []byte) error {
+	var b Batch
+	b.Put(key, value)
+	return t.Write(&b)
+}
+
+// Delete removes key in its own atomic write. Deleting a missing key is not an error.
+func (t *BTree) Delete(key []byte) error {
+	var b Batch
+	b.Delete(key)
+	return t.Write(&b)
+}
+
+// Batch groups puts and deletes that Write applies atomically: after a crash either all
+// of them are visible or none is.
+type Batch struct {
+	ops []batchOp
+}
+
+type batchOp struct {
+	key, value []byte
+	delete     bool
+}
+
+func (b *Batch) Put(key, value []byte) {
+	b.ops = append(b.ops, batchOp{key: bytes.Clone(key), value: bytes.Clone(value)})
+}
+
+func (b *Batch) Delete(key []byte) {
+	b.ops = append(b.ops, batchOp{key: bytes.Clone(key), delete: true})
+}
+
+// Append adds the operations of other to the end of b.
+func (b *Batch) Append(other *Batch) {
+	b.ops = append(b.ops, other.ops...)
+}
+
+// Len returns the number of operations in the batch.
+func (b *Batch) Len() int { return len(b.ops) }
+
+// Write applies the batch in order and commits it with a single journal write.
+func (t *BTree) Write(b *Batch) error {
+	for _, op := range b.ops {
+		if len(op.key) == 0 {
+			return ErrEmptyKey
+		}
+		if len(op.key) > MaxKeySize || len(op.value) > MaxValueSize {
+			return ErrEntryTooLarge
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.usable(); err != nil {
+		return err
+	}
+	root, count := t.root, t.count
+	for _, op := range b.ops {
+		var err error
+		if op.delete {
+			err = t.delete(op.key)
+		} else {
+			err = t.put(op.key, op.value)
+		}
+		if err != nil {
+			t.root, t.count = root, count
+			t.pager.rollback()
+			return err
+		}
+	}
+	if err := t.writeMeta(); err != nil {
+		t.root, t.count = root, count
+		t.pager.rollback()
+		return err
+	}
+	if err := t.pager.commit(); err != nil {
+		t.failed = fmt.Errorf("storage: commit failed, reopen the tree: %w", err)
+		return err
+	}
+	return nil
+}
+
+// Scan calls fn for every key greater than or equal to from, in order, until fn returns
+// false. An empty from starts at the smallest key. fn receives copies it may keep.
+func (t *BTree) Scan(from []byte, fn func(key, value []byte) bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.usable(); err != nil {
+		return err
+	}
+	n, _, err := t.findLeaf(from)
+	if err != nil {
+		return err
+	}
+	i, _ := n.search(from)
+	for {
+		for ; i < len(n.keys); i++ {
+			if !fn(n.keys[i], n.values[i]) {
+				return nil
+			}
+		}
+		if n.next == 0 {
+			return nil
+		}
+		if n, err = t.load(n.next); err != nil {
+			return err
+		}
+		i = 0
+	}
+}
+
+// Close releases the file. Every successful Write is already durable, so there is
+// nothing left to flush.
+func (t *BTree) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pager == nil {
+		return ErrClosed
+	}
+	err := t.pager.file.Close()
+	t.pager = nil
+	return err
+}
+
+func (t *BTree) usable() error {
+	if t.pager == nil {
+		return ErrClosed
+	}
+	return t.failed
+}
+
+func (t *BTree) put(key, value []byte) error {
+	split, inserted, err := t.insert(t.root, key, value)
+	if err != nil {
+		return err
+	}
+	if inserted {
+		t.count++
+	}
+	if split == nil {
+		return nil
+	}
+	root := t.pager.allocate()
+	encodeNode(root, &node{keys: [][]byte{split.key}, children: []pageID{t.root, split.right}})
+	t.root = root.id
+	return nil
+}
+
+// splitResult tells the parent that a child split: key is the first key reachable
+// through the new right sibling.
+type splitResult struct {
+	key   []byte
+	right pageID
+}
+
+func (t *BTree) insert(id pageID, key, value []byte) (*splitResult, bool, error) {
+	n, err := t.load(id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	inserted := false
+	if n.leaf {
+		i, found := n.search(key)
+		if found {
+			n.values[i] = value
+		} else {
+			n.keys = insertAt(n.keys, i, key)
+			n.values = insertAt(n.values, i, value)
+			inserted = true
+		}
+	} else {
+		i := n.childIndex(key)
+		split, added, err := t.insert(n.children[i], key, value)
+		if err != nil {
+			return nil, false, err
+		}
+		inserted = added
+		if split == nil {
+			return nil, inserted, nil
+		}
+		n.keys = insertAt(n.keys, i, split.key)
+		n.children = insertAt(n.children, i+1, split.right)
+	}
+
+	if n.encodedSize() <= nodePayload {
+		return nil, inserted, t.store(id, n)
+	}
+	split, err := t.split(id, n)
+	return split, inserted, err
+}
+
+// split moves the upper part of an overfull node into a new right sibling. It cuts at
+// the first entry where the left half reaches half the encoded size, so both halves fit
+// even when entry sizes vary a lot.
+func (t *BTree) split(id pageID, n *node) (*splitResult, error) {
+	half, size, mid := n.encodedSize()/2, 0, 0
+	for mid = 0; mid < len(n.keys)-1 && size < half; mid++ {
+		size += n.entrySize(mid)
+	}
+	mid = max(mid, 1)
+
+	rightPage := t.pager.allocate()
+	right := &node{leaf: n.leaf}
+	var sep []byte
+	if n.leaf {
+		right.keys = append(right.keys, n.keys[mid:]...)
+		right.values = append(right.values, n.values[mid:]...)
+		right.next = n.next
+		n.keys, n.values = n.keys[:mid], n.values[:mid]
+		n.next = rightPage.id
+		sep = right.keys[0]
+	} else {
+		// The middle key moves up to the parent and keeps no copy in either half.
+		sep = n.keys[mid]
+		right.keys = append(right.keys, n.keys[mid+1:]...)
+		right.children = append(right.children, n.children[mid+1:]...)
+		n.keys, n.children = n.keys[:mid], n.children[:mid+1]
+	}
+	encodeNode(rightPage, right)
+	if err := t.store(id, n); err != nil {
+		return nil, err
+	}
+	return &splitResult{key: sep, right: rightPage.id}, nil
+}
+
+func (t *BTree) delete(key []byte) error {
+	n, id, err := t.findLeaf(key)
+	if err != nil {
+		return err
+	}
+	i, found := n.search(key)
+	if !found {
+		return nil
+	}
+	n.keys = append(n.keys[:i], n.keys[i+1:]...)
+	n.values = append(n.values[:i], n.values[i+1:]...)
+	t.count--
+	return t.store(id, n)
+}
+
+func (t *BTree) findLeaf(key []byte) (*node, pageID, error) {
+	id := t.root
+	for {
+		n, err := t.load(id)
+		if err != nil {
+			return nil, 0, err
+		}
+		if n.leaf {
+			return n, id, nil
+		}
+		id = n.children[n.childIndex(key)]
+	}
+}
+
+func (t *BTree) load(id pageID) (*node, error) {
+	pg, err := t.pager.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return decodeNode(pg)
+}
+
+func (t *BTree) store(id pageID, n *node) error {
+	pg, err := t.pager.writable(id)
+	if err != nil {
+		return err
+	}
+	encodeNode(pg, n)
+	return nil
+}
+
+// search returns the position of key in a leaf, or where it would be inserted.
+func (n *node) search(key []byte) (int, bool) {
+	i := sort.Search(len(n.keys), func(i int) bool { return bytes.Compare(n.keys[i], key) >= 0 })
+	return i, i < len(n.keys) && bytes.Equal(n.keys[i], key)
+}
+
+// childIndex picks the child of an internal node whose range contains key: child i
+// holds the keys in [keys[i-1], keys[i]).
+func (n *node) childIndex(key []byte) int {
+	return sort.Search(len(n.keys), func(i int) bool { return bytes.Compare(n.keys[i], key) > 0 })
+}
+
+func (n *node) entrySize(i int) int {
+	if n.leaf {
+		return 4 + len(n.keys[i]) + len(n.values[i])
+	}
+	return 6 + len(n.keys[i])
+}
+
+func (n *node) encodedSize() int {
+	size := 0
+	if !n.leaf {
+		size = 4
+	}
+	for i := range n.keys {
+		size += n.entrySize(i)
+	}
+	return size
+}
+
+// encodeNode writes n into the page. Leaf entries are (keyLen u16, valueLen u16, key,
+// value); internal nodes start with the first child and then hold (keyLen u16, key,
+// child u32) per key.
+func encodeNode(pg *page, n *node) {
+	buf := pg.data[:checksumOffset]
+	clear(buf)
+	buf[0] = kindInternal
+	if n.leaf {
+		buf[0] = kindLeaf
+	}
+	binary.LittleEndian.PutUint16(buf[1:], uint16(len(n.keys)))
+	binary.LittleEndian.PutUint32(buf[3:], uint32(n.next))
+
+	off := nodeHeader
+	if !n.leaf {
+		binary.LittleEndian.PutUint32(buf[off:], uint32(n.children[0]))
+		off += 4
+	}
+	for i, key := range n.keys {
+		if n.leaf {
+			binary.LittleEndian.PutUint16(buf[off:], uint16(len(key)))
+			binary.LittleEndian.PutUint16(buf[off+2:], uint16(len(n.values[i])))
+			off += 4
+			off += copy(buf[off:], key)
+			off += copy(buf[off:], n.values[i])
+			continue
+		}
+		binary.LittleEndian.PutUint16(buf[off:], uint16(len(key)))
+		off += 2
+		off += copy(buf[off:], key)
+		binary.LittleEndian.PutUint32(buf[off:], uint32(n.children[i+1]))
+		off += 4
+	}
+}
+
+func decodeNode(pg *page) (*node, error) {
+	buf := pg.data[:checksumOffset]
+	kind := buf[0]
+	if kind != kindLeaf && kind != kindInternal {
+		return nil, fmt.Errorf("%w: page %d has unknown kind %d", ErrCorruptPage, pg.id, kind)
+	}
+	count := int(binary.LittleEndian.Uint16(buf[1:]))
+	n := &node{
+		leaf: kind == kindLeaf,
+		keys: make([][]byte, count),
+		next: pageID(binary.LittleEndian.Uint32(buf[3:])),
+	}
+
+	off := nodeHeader
+	if n.leaf {
+		n.values = make([][]byte, count)
+		for i := 0; i < count; i++ {
+			keyLen := int(binary.LittleEndian.Uint16(buf[off:]))
+			valueLen := int(binary.LittleEndian.Uint16(buf[off+2:]))
+			off += 4
+			n.keys[i] = bytes.Clone(buf[off : off+keyLen])
+			off += keyLen
+			n.values[i] = bytes.Clone(buf[off : off+valueLen])
+			off += valueLen
+		}
+		return n, nil
+	}
+
+	n.children = make([]pageID, 0, count+1)
+	n.children = append(n.children, pageID(binary.LittleEndian.Uint32(buf[off:])))
+	off += 4
+	for i := 0; i < count; i++ {
+		keyLen := int(binary.LittleEndian.Uint16(buf[off:]))
+		off += 2
+		n.keys[i] = bytes.Clone(buf[off : off+keyLen])
+		off += keyLen
+		n.children = append(n.children, pageID(binary.LittleEndian.Uint32(buf[off:])))
+		off += 4
+	}
+	return n, nil
+}
+
+func insertAt[T any](s []T, i int, v T) []T {
+	var zero T
+	s = append(s, zero)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
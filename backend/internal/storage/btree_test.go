@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestBTreePutGetScanAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.db")
+	// A tiny pool forces pages in and out of the buffer pool.
+	tree, err := Open(path, Options{PoolSize: 4})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	want := make(map[string]string)
+	for _, i := range rng.Perm(3000) {
+		key := fmt.Sprintf("b%05d", i)
+		value := fmt.Sprintf("book-%d-%s", i, bytes.Repeat([]byte("x"), rng.Intn(200)))
+		if err := tree.Put([]byte(key), []byte(value)); err != nil {
+			t.Fatalf("put %s: %v", key, err)
+		}
+		want[key] = value
+	}
+	for i := 0; i < 3000; i += 3 {
+		key := fmt.Sprintf("b%05d", i)
+		if err := tree.Delete([]byte(key)); err != nil {
+			t.Fatalf("delete %s: %v", key, err)
+		}
+		delete(want, key)
+	}
+	if err := tree.Put([]byte("b00001"), []byte("replaced")); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	want["b00001"] = "replaced"
+
+	check := func(tree *BTree) {
+		t.Helper()
+		if tree.Len() != len(want) {
+			t.Fatalf("expected %d keys, got %d", len(want), tree.Len())
+		}
+		for key, value := range want {
+			got, ok, err := tree.Get([]byte(key))
+			if err != nil || !ok || string(got) != value {
+				t.Fatalf("get %s: ok=%v err=%v", key, ok, err)
+			}
+		}
+		if _, ok, _ := tree.Get([]byte("b00000")); ok {
+			t.Fatalf("deleted key still present")
+		}
+
+		keys := make([]string, 0, len(want))
+		for key := range want {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		scanned := make([]string, 0)
+		if err := tree.Scan(nil, func(k, _ []byte) bool { scanned = append(scanned, string(k)); return true }); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		if len(scanned) != len(keys) {
+			t.Fatalf("scan returned %d keys, expected %d", len(scanned), len(keys))
+		}
+		for i := range keys {
+			if scanned[i] != keys[i] {
+				t.Fatalf("scan out of order at %d: %s vs %s", i, scanned[i], keys[i])
+			}
+		}
+
+		page := make([]string, 0)
+		tree.Scan([]byte("b01500"), func(k, _ []byte) bool { page = append(page, string(k)); return len(page) < 3 })
+		if len(page) != 3 || page[0] != "b01501" || page[2] != "b01504" {
+			t.Fatalf("unexpected scan page: %v", page)
+		}
+	}
+
+	check(tree)
+	if err := tree.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	reopened, err := Open(path, Options{PoolSize: 4})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	check(reopened)
+}
+
+func TestBTreeReplaysJournalAfterCrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.db")
+	tree, err := Open(path, Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := tree.Put([]byte("a"), []byte("1")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	// Crash after the journal is synced but before any page reaches the data file.
+	tree.pager.crashAfterJournal = true
+	var b Batch
+	b.Put([]byte("b"), []byte("2"))
+	b.Delete([]byte("a"))
+	if err := tree.Write(&b); !errors.Is(err, errSimulatedCrash) {
+		t.Fatalf("expected simulated crash, got %v", err)
+	}
+	if err := tree.Put([]byte("c"), []byte("3")); err == nil {
+		t.Fatalf("a tree whose commit failed must refuse further writes")
+	}
+	tree.pager.file.Close()
+
+	recovered, err := Open(path, Options{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer recovered.Close()
+	if _, ok, _ := recovered.Get([]byte("a")); ok {
+		t.Fatalf("journaled delete of a was not replayed")
+	}
+	if v, ok, _ := recovered.Get([]byte("b")); !ok || string(v) != "2" {
+		t.Fatalf("journaled put of b was not replayed")
+	}
+	if _, err := os.Stat(path + ".journal"); !os.IsNotExist(err) {
+		t.Fatalf("journal should be removed after recovery")
+	}
+}
+
+func TestBTreeDiscardsTornJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.db")
+	tree, err := Open(path, Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := tree.Put([]byte("a"), []byte("1")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	tree.Close()
+
+	// A journal cut short by a crash has no valid trailer; the data file was never
+	// written, so the last committed state must survive untouched.
+	if err := os.WriteFile(path+".journal", bytes.Repeat([]byte{0xff}, PageSize), 0o644); err != nil {
+		t.Fatalf("write journal: %v", err)
+	}
+	recovered, err := Open(path, Options{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer recovered.Close()
+	if v, ok, _ := recovered.Get([]byte("a")); !ok || string(v) != "1" {
+		t.Fatalf("committed data lost after torn journal")
+	}
+}
+
+func TestBTreeDetectsCorruptPage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.db")
+	tree, err := Open(path, Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	tree.Put([]byte("a"), []byte("1"))
+	tree.Close()
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+	f.WriteAt([]byte{0x42}, PageSize+20)
+	f.Close()
+
+	reopened, err := Open(path, Options{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	if _, _, err := reopened.Get([]byte("a")); !errors.Is(err, ErrCorruptPage) {
+		t.Fatalf("expected corrupt page error, got %v", err)
+	}
+}
+
+func TestBTreeRejectsOversizedEntries(t *testing.T) {
+	tree, err := Open(filepath.Join(t.TempDir(), "catalog.db"), Options{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer tree.Close()
+	if err := tree.Put(make([]byte, MaxKeySize+1), nil); !errors.Is(err, ErrEntryTooLarge) {
+		t.Fatalf("expected ErrEntryTooLarge, got %v", err)
+	}
+	if err := tree.Put(nil, []byte("x")); !errors.Is(err, ErrEmptyKey) {
+		t.Fatalf("expected ErrEmptyKey, got %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"sort"
+
+	"library/internal/ds"
+)
+
+// PageSize is the fixed size of every page in the data file.
+const PageSize = 4096
+
+// checksumOffset is where each page stores the CRC32 of the bytes before it.
+const checksumOffset = PageSize - 4
+
+var journalMagic = [8]byte{'L', 'I', 'B', 'J', 'R', 'N', 'L', '1'}
+
+// ErrCorruptPage is returned when a page read from disk fails its checksum.
+var ErrCorruptPage = errors.New("storage: corrupt page")
+
+// errSimulatedCrash is returned by commit when crashAfterJournal is set; tests use it to
+// stop a commit between the journal and the in-place page writes.
+var errSimulatedCrash = errors.New("storage: simulated crash")
+
+type pageID uint32
+
+type page struct {
+	id   pageID
+	data [PageSize]byte
+}
+
+// pager reads and writes fixed-size pages through a buffer pool. Changed pages stay in
+// the dirty set until commit, which makes them durable atomically: they are first
+// written to a journal file and synced, then copied into the data file, and only then
+// is the journal removed. A journal left behind by a crash is replayed on open; a torn
+// one (bad trailer) means the data file was never touched and it is discarded.
+type pager struct {
+	file        *os.File
+	journalPath string
+	pageCount   uint32
+	committed   uint32
+	clean       *ds.LRU[pageID, *page]
+	dirty       map[pageID]*page
+
+	crashAfterJournal bool
+}
+
+func openPager(path string, poolSize int) (*pager, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	p := &pager{
+		file:        file,
+		journalPath: path + ".journal",
+		clean:       ds.NewLRU[pageID, *page](poolSize, nil),
+		dirty:       make(map[pageID]*page),
+	}
+	if err := p.recover(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.Size()%PageSize != 0 {
+		file.Close()
+		return nil, fmt.Errorf("storage: data file size %d is not a multiple of the page size", info.Size())
+	}
+	p.pageCount = uint32(info.Size() / PageSize)
+	p.committed = p.pageCount
+	return p, nil
+}
+
+// get returns the page with the given ID, reading it from disk on a pool miss. The
+// result must be treated as read-only; use writable to change a page.
+func (p *pager) get(id pageID) (*page, error) {
+	if pg, ok := p.dirty[id]; ok {
+		return pg, nil
+	}
+	if pg, ok := p.clean.Get(id); ok {
+		return pg, nil
+	}
+	if uint32(id) >= p.pageCount {
+		return nil, fmt.Errorf("storage: page %d out of range", id)
+	}
+	pg := &page{id: id}
+	if _, err := p.file.ReadAt(pg.data[:], int64(id)*PageSize); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(pg.data[checksumOffset:]) != crc32.ChecksumIEEE(pg.data[:checksumOffset]) {
+		return nil, fmt.Errorf("%w: page %d", ErrCorruptPage, id)
+	}
+	p.clean.Put(id, pg)
+	return pg, nil
+}
+
+func (p *pager) allocate() *page {
+	pg := &page{id: pageID(p.pageCount)}
+	p.pageCount++
+	p.dirty[pg.id] = pg
+	return pg
+}
+
+// writable returns a private copy of the page that joins the dirty set, so the pooled
+// committed version stays intact if the change is rolled back.
+func (p *pager) writable(id pageID) (*page, error) {
+	if pg, ok := p.dirty[id]; ok {
+		return pg, nil
+	}
+	pg, err := p.get(id)
+	if err != nil {
+		return nil, err
+	}
+	copied := *pg
+	p.dirty[id] = &copied
+	return &copied, nil
+}
+
+// rollback drops every uncommitted change.
+func (p *pager) rollback() {
+	p.dirty = make(map[pageID]*page)
+	p.pageCount = p.committed
+}
+
+func (p *pager) commit() error {
+	if len(p.dirty) == 0 {
+		return nil
+	}
+	pages := make([]*page, 0, len(p.dirty))
+	for _, pg := range p.dirty {
+		binary.LittleEndian.PutUint32(pg.data[checksumOffset:], crc32.ChecksumIEEE(pg.data[:checksumOffset]))
+		pages = append(pages, pg)
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].id < pages[j].id })
+
+	if err := p.writeJournal(pages); err != nil {
+		return err
+	}
+	if p.crashAfterJournal {
+		return errSimulatedCrash
+	}
+	if err := p.writePages(pages); err != nil {
+		return err
+	}
+	if err := os.Remove(p.journalPath); err != nil {
+		return err
+	}
+
+	for _, pg := range pages {
+		p.clean.Put(pg.id, pg)
+	}
+	p.dirty = make(map[pageID]*page)
+	p.committed = p.pageCount
+	return nil
+}
+
+// writeJournal stores the pages as (id, data) records followed by a trailer with the
+// record count, a CRC32 of the records and the magic, then syncs the file.
+func (p *pager) writeJournal(pages []*page) error {
+	buf := make([]byte, 0, len(pages)*(4+PageSize)+16)
+	for _, pg := range pages {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(pg.id))
+		buf = append(buf, pg.data[:]...)
+	}
+	sum := crc32.ChecksumIEEE(buf)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(pages)))
+	buf = binary.LittleEndian.AppendUint32(buf, sum)
+	buf = append(buf, journalMagic[:]...)
+
+	journal, err := os.OpenFile(p.journalPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := journal.Write(buf); err != nil {
+		journal.Close()
+		return err
+	}
+	if err := journal.Sync(); err != nil {
+		journal.Close()
+		return err
+	}
+	return journal.Close()
+}
+
+func (p *pager) writePages(pages []*page) error {
+	for _, pg := range pages {
+		if _, err := p.file.WriteAt(pg.data[:], int64(pg.id)*PageSize); err != nil {
+			return err
+		}
+	}
+	return p.file.Sync()
+}
+
+func (p *pager) recover() error {
+	raw, err := os.ReadFile(p.journalPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	pages, ok := decodeJournal(raw)
+	if ok {
+		if err := p.writePages(pages); err != nil {
+			return err
+		}
+	}
+	return os.Remove(p.journalPath)
+}
+
+func decodeJournal(raw []byte) ([]*page, bool) {
+	const trailer = 16
+	if len(raw) < trailer || [8]byte(raw[len(raw)-8:]) != journalMagic {
+		return nil, false
+	}
+	body := raw[:len(raw)-trailer]
+	count := binary.LittleEndian.Uint32(raw[len(raw)-trailer:])
+	sum := binary.LittleEndian.Uint32(raw[len(raw)-trailer+4:])
+	if crc32.ChecksumIEEE(body) != sum || len(body) != int(count)*(4+PageSize) {
+		return nil, false
+	}
+	pages := make([]*page, 0, count)
+	for len(body) > 0 {
+		pg := &page{id: pageID(binary.LittleEndian.Uint32(body))}
+		copy(pg.data[:], body[4:4+PageSize])
+		pages = append(pages, pg)
+		body = body[4+PageSize:]
+	}
+	return pages, true
+}
@@ -72,10 +72,10 @@ export default function BookList({ refreshKey = 0 }) {
                 <td>{b.id}</td>
                 <td>{b.title}</td>
                 <td>{b.author}</td>
-                <td>{b.available? 'Sí':'No'}</td>
+                <td>{`${b.availableCopies ?? 0} de ${b.copies ?? 0}`}</td>
                 <td className="row" style={{gap:6}}>
                   <button className="btn success" onClick={()=>borrow(b.id)} disabled={!b.available || !userId}>Prestar</button>
-                  <button className="btn danger" onClick={()=>ret(b.id)} disabled={b.available || !userId}>Devolver</button>
+                  <button className="btn danger" onClick={()=>ret(b.id)} disabled={b.availableCopies === b.copies || !userId}>Devolver</button>
                   <button className="btn secondary" onClick={()=>removeBook(b.id)}>Eliminar</button>
                 </td>
               </tr>